@@ -0,0 +1,162 @@
+// Package dataurl implements the data: URL processor of the Fetch Standard
+// (https://fetch.spec.whatwg.org/#data-url-processor), which splits a data:
+// URL into its MIME type and its decoded payload.
+package dataurl
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/oleiade/sobek-webapi-url/mimetype"
+	"github.com/oleiade/sobek-webapi-url/url"
+)
+
+var (
+	// ErrNotDataURL is returned for valid URLs whose scheme is not data.
+	ErrNotDataURL = errors.New("not a data: URL")
+
+	// ErrInvalidDataURL is returned for data: URLs the processor rejects,
+	// such as those without a comma or with an invalid base64 payload.
+	ErrInvalidDataURL = errors.New("invalid data: URL")
+)
+
+// DataURL is a processed data: URL.
+type DataURL struct {
+	// MIMEType is the MIME type of the payload. It defaults to
+	// "text/plain;charset=US-ASCII" when the URL does not carry a valid one.
+	MIMEType mimetype.MIMEType
+
+	// Base64 reports whether the payload was base64-encoded.
+	Base64 bool
+
+	// Body is the decoded payload.
+	Body []byte
+}
+
+// ParseDataURL parses input with the WHATWG URL parser and processes the
+// resulting data: URL. The fragment, if any, is ignored.
+func ParseDataURL(input string) (*DataURL, error) {
+	u, err := url.NewURL(input, "")
+	if err != nil {
+		return nil, err
+	}
+	return FromURL(u)
+}
+
+// FromURL processes u, which must be a data: URL.
+func FromURL(u *url.URL) (*DataURL, error) {
+	if u.Protocol() != "data:" {
+		return nil, ErrNotDataURL
+	}
+
+	input := strings.TrimPrefix(u.Serialize(true), "data:")
+
+	mimeType, encodedBody, found := strings.Cut(input, ",")
+	if !found {
+		return nil, ErrInvalidDataURL
+	}
+	mimeType = strings.Trim(mimeType, asciiWhitespace)
+
+	body := percentDecode(encodedBody)
+
+	d := &DataURL{}
+	if rest, ok := cutBase64Suffix(mimeType); ok {
+		decoded, ok := forgivingBase64Decode(string(body))
+		if !ok {
+			return nil, ErrInvalidDataURL
+		}
+		body = decoded
+		mimeType = rest
+		d.Base64 = true
+	}
+	d.Body = body
+
+	if strings.HasPrefix(mimeType, ";") {
+		mimeType = "text/plain" + mimeType
+	}
+	parsed, err := mimetype.Parse(mimeType)
+	if err != nil {
+		parsed = mimetype.MIMEType{
+			Type:    "text",
+			Subtype: "plain",
+			Params:  []mimetype.Param{{Name: "charset", Value: "US-ASCII"}},
+		}
+	}
+	d.MIMEType = parsed
+
+	return d, nil
+}
+
+// asciiWhitespace holds the ASCII whitespace code points.
+const asciiWhitespace = "\t\n\f\r "
+
+// cutBase64Suffix removes a trailing ";base64", optionally with spaces
+// before "base64", from mimeType.
+func cutBase64Suffix(mimeType string) (string, bool) {
+	const suffix = "base64"
+	if len(mimeType) < len(suffix) || !strings.EqualFold(mimeType[len(mimeType)-len(suffix):], suffix) {
+		return "", false
+	}
+	rest := strings.TrimRight(mimeType[:len(mimeType)-len(suffix)], " ")
+	if !strings.HasSuffix(rest, ";") {
+		return "", false
+	}
+	return rest[:len(rest)-1], true
+}
+
+// forgivingBase64Decode implements
+// https://infra.spec.whatwg.org/#forgiving-base64-decode.
+func forgivingBase64Decode(s string) ([]byte, bool) {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(asciiWhitespace, r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	if len(s)%4 == 0 {
+		s = strings.TrimSuffix(s, "=")
+		s = strings.TrimSuffix(s, "=")
+	}
+	if len(s)%4 == 1 || strings.Contains(s, "=") {
+		return nil, false
+	}
+
+	decoded, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// percentDecode implements https://url.spec.whatwg.org/#percent-decode,
+// leaving invalid percent-encoded sequences as-is.
+func percentDecode(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			hi, lo := unhex(s[i+1]), unhex(s[i+2])
+			if hi >= 0 && lo >= 0 {
+				b = append(b, byte(hi<<4|lo))
+				i += 2
+				continue
+			}
+		}
+		b = append(b, s[i])
+	}
+	return b
+}
+
+// unhex returns the value of a hex digit, or -1 if invalid.
+func unhex(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c - 'a' + 10)
+	case c >= 'A' && c <= 'F':
+		return int(c - 'A' + 10)
+	}
+	return -1
+}
@@ -0,0 +1,91 @@
+package dataurl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDataURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input    string
+		mimeType string
+		base64   bool
+		body     string
+	}{
+		{
+			input:    "data:,Hello%2C%20World!",
+			mimeType: "text/plain;charset=US-ASCII",
+			body:     "Hello, World!",
+		},
+		{
+			input:    "data:text/plain;base64,SGVsbG8sIFdvcmxkIQ==",
+			mimeType: "text/plain",
+			base64:   true, body: "Hello, World!",
+		},
+		{
+			input:    "data:Text/HTML;Charset=UTF-8,%3Ch1%3Ehi%3C%2Fh1%3E",
+			mimeType: "text/html;charset=UTF-8",
+			body:     "<h1>hi</h1>",
+		},
+		{
+			input:    "data:application/json;  BASE64,eyJhIjoxfQ",
+			mimeType: "application/json",
+			base64:   true, body: `{"a":1}`,
+		},
+		{
+			input:    "data:;charset=utf-8,x",
+			mimeType: "text/plain;charset=utf-8",
+			body:     "x",
+		},
+		{
+			input:    "data:text,x",
+			mimeType: "text/plain;charset=US-ASCII",
+			body:     "x",
+		},
+		{
+			input:    "data:;base64,YW Jj ZA%3D%3D#fragment",
+			mimeType: "text/plain;charset=US-ASCII",
+			base64:   true, body: "abcd",
+		},
+		{
+			input:    "data:text/plain;charset=\"utf-8\";base64,eA",
+			mimeType: "text/plain;charset=utf-8",
+			base64:   true, body: "x",
+		},
+		{
+			input:    "DATA:image/png,%89PNG",
+			mimeType: "image/png",
+			body:     "\x89PNG",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := ParseDataURL(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.mimeType, d.MIMEType.String())
+			require.Equal(t, tc.base64, d.Base64)
+			require.Equal(t, tc.body, string(d.Body))
+		})
+	}
+}
+
+func TestParseDataURLErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseDataURL("https://example.com/")
+	require.ErrorIs(t, err, ErrNotDataURL)
+
+	for _, input := range []string{"data:text/plain", "data:;base64,a", "data:;base64,ab=c", "data:;base64,a*bc"} {
+		_, err := ParseDataURL(input)
+		require.ErrorIs(t, err, ErrInvalidDataURL, "input %q", input)
+	}
+
+	_, err = ParseDataURL("not a URL")
+	require.Error(t, err)
+}
@@ -0,0 +1,626 @@
+// Package hostparser implements the WHATWG host parser
+// (https://url.spec.whatwg.org/#host-parsing), which turns the host of a URL
+// into a domain, an IPv4 address, an IPv6 address or an opaque host.
+//
+// It is the host parser the url package uses, exposed on its own so that
+// hosts can be parsed without constructing a whole URL.
+package hostparser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrInvalidHost is returned for inputs the host parser rejects.
+var ErrInvalidHost = errors.New("invalid host")
+
+// Kind identifies which kind of host a Host value holds.
+type Kind int
+
+const (
+	// KindDomain is an ASCII domain such as "example.com".
+	KindDomain Kind = iota
+	// KindIPv4 is an IPv4 address such as "127.0.0.1".
+	KindIPv4
+	// KindIPv6 is an IPv6 address such as "[::1]".
+	KindIPv6
+	// KindOpaque is the host of a non-special URL, such as "EXAMPLE" in
+	// "foo://EXAMPLE/", kept as-is apart from percent-encoding.
+	KindOpaque
+	// KindEmpty is the empty host of URLs such as "file:///tmp" or "foo:///".
+	KindEmpty
+)
+
+// Host is a parsed WHATWG host (https://url.spec.whatwg.org/#concept-host).
+type Host struct {
+	// Kind tells which of the fields below is meaningful.
+	Kind Kind
+
+	// Domain holds the ASCII domain when Kind is KindDomain.
+	Domain string
+
+	// IPv4 holds the address when Kind is KindIPv4.
+	IPv4 uint32
+
+	// IPv6 holds the eight 16-bit pieces of the address when Kind is KindIPv6.
+	IPv6 [8]uint16
+
+	// Opaque holds the percent-encoded host when Kind is KindOpaque.
+	Opaque string
+}
+
+// String returns the host serialization
+// (https://url.spec.whatwg.org/#concept-host-serializer).
+func (h Host) String() string {
+	switch h.Kind {
+	case KindIPv4:
+		return serializeIPv4(h.IPv4)
+	case KindIPv6:
+		return "[" + serializeIPv6(h.IPv6) + "]"
+	case KindOpaque:
+		return h.Opaque
+	case KindEmpty:
+		return ""
+	default:
+		return h.Domain
+	}
+}
+
+// Options tunes how ParseHostWithOptions parses its input.
+type Options struct {
+	// OnValidationError, when non-nil, is called with the name of every
+	// validation error the parser recovers from: "IPv4-empty-part",
+	// "IPv4-non-decimal-part" or "invalid-URL-unit".
+	OnValidationError func(errorType string)
+}
+
+// ParseHost parses input with the WHATWG host parser. isSpecial tells
+// whether the host belongs to a URL with a special scheme, such as http:
+// only those hosts are IDNA-processed and may be IPv4 addresses, the others
+// are opaque.
+func ParseHost(input string, isSpecial bool) (Host, error) {
+	return ParseHostWithOptions(input, isSpecial, Options{})
+}
+
+// ParseHostWithOptions is like ParseHost but configured with opts.
+func ParseHostWithOptions(input string, isSpecial bool, opts Options) (Host, error) {
+	report := opts.OnValidationError
+	if strings.HasPrefix(input, "[") {
+		if !strings.HasSuffix(input, "]") {
+			return Host{}, ErrInvalidHost
+		}
+		pieces, ok := parseIPv6(input[1 : len(input)-1])
+		if !ok {
+			return Host{}, ErrInvalidHost
+		}
+		return Host{Kind: KindIPv6, IPv6: pieces}, nil
+	}
+
+	if !isSpecial {
+		return parseOpaqueHost(input, report)
+	}
+
+	// Decoding may produce invalid UTF-8, which the rune conversion turns
+	// into U+FFFD as "UTF-8 decode without BOM" does.
+	domain := string([]rune(percentDecode(input)))
+
+	asciiDomain, ok := DomainToASCII(domain)
+	if !ok {
+		return Host{}, ErrInvalidHost
+	}
+
+	if endsInANumber(asciiDomain) {
+		addr, ok := parseIPv4(asciiDomain)
+		if !ok {
+			return Host{}, ErrInvalidHost
+		}
+		if report != nil {
+			reportIPv4ValidationErrors(asciiDomain, report)
+		}
+		return Host{Kind: KindIPv4, IPv4: addr}, nil
+	}
+
+	return Host{Kind: KindDomain, Domain: asciiDomain}, nil
+}
+
+// parseOpaqueHost implements https://url.spec.whatwg.org/#concept-opaque-host-parser.
+func parseOpaqueHost(input string, report func(string)) (Host, error) {
+	if input == "" {
+		return Host{Kind: KindEmpty}, nil
+	}
+	for i := 0; i < len(input); i++ {
+		if isForbiddenHostCodePoint(input[i]) {
+			return Host{}, ErrInvalidHost
+		}
+	}
+	if report != nil && !isValidOpaqueHost(input) {
+		report("invalid-URL-unit")
+	}
+	return Host{Kind: KindOpaque, Opaque: percentEncodeC0Control(input)}, nil
+}
+
+// isValidOpaqueHost reports whether input only holds URL code points and
+// well-formed percent-encoded bytes.
+func isValidOpaqueHost(input string) bool {
+	runes := []rune(input)
+	for i, c := range runes {
+		if c != '%' {
+			if !isURLCodePoint(c) {
+				return false
+			}
+			continue
+		}
+		if i+2 >= len(runes) || !isHexRune(runes[i+1]) || !isHexRune(runes[i+2]) {
+			return false
+		}
+	}
+	return true
+}
+
+//nolint:gochecknoglobals // Immutable IDNA profile shared by every parse.
+var idnaProfile = idna.New(
+	idna.MapForLookup(),
+	idna.Transitional(false),
+	idna.StrictDomainName(false),
+	idna.CheckHyphens(false),
+	idna.VerifyDNSLength(false),
+	idna.BidiRule(),
+)
+
+// DomainToASCII implements https://url.spec.whatwg.org/#concept-domain-to-ascii
+// with beStrict set to false, followed by the forbidden domain code point
+// check. It reports false for domains the host parser rejects.
+func DomainToASCII(domain string) (string, bool) {
+	var result string
+	if isASCII(domain) && !hasPunycodeLabel(domain) {
+		// The spec allows skipping UTS #46 processing for such domains, as
+		// it would only lowercase them.
+		result = strings.ToLower(domain)
+	} else {
+		var err error
+		if result, err = idnaProfile.ToASCII(domain); err != nil {
+			return "", false
+		}
+	}
+	if result == "" {
+		return "", false
+	}
+
+	for i := 0; i < len(result); i++ {
+		if isForbiddenDomainCodePoint(result[i]) {
+			return "", false
+		}
+	}
+
+	return result, true
+}
+
+// DomainToUnicode converts an ASCII (punycode) domain to its Unicode form,
+// as https://url.spec.whatwg.org/#concept-domain-to-unicode does.
+func DomainToUnicode(domain string) (string, bool) {
+	result, err := idnaProfile.ToUnicode(domain)
+	if err != nil {
+		return "", false
+	}
+	return result, true
+}
+
+// isASCII reports whether s only contains ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPunycodeLabel reports whether a label of domain starts with an ASCII
+// case-insensitive match for "xn--".
+func hasPunycodeLabel(domain string) bool {
+	for _, label := range strings.Split(domain, ".") {
+		if len(label) >= 4 && strings.EqualFold(label[:4], "xn--") {
+			return true
+		}
+	}
+	return false
+}
+
+// isForbiddenHostCodePoint reports whether c is a forbidden host code point.
+func isForbiddenHostCodePoint(c byte) bool {
+	switch c {
+	case 0x00, '\t', '\n', '\r', ' ', '#', '/', ':', '<', '>', '?', '@', '[', '\\', ']', '^', '|':
+		return true
+	}
+	return false
+}
+
+// isForbiddenDomainCodePoint reports whether c is a forbidden domain code point.
+func isForbiddenDomainCodePoint(c byte) bool {
+	return isForbiddenHostCodePoint(c) || c <= 0x1F || c == '%' || c == 0x7F
+}
+
+// endsInANumber implements https://url.spec.whatwg.org/#ends-in-a-number-checker.
+func endsInANumber(input string) bool {
+	parts := strings.Split(input, ".")
+	if parts[len(parts)-1] == "" {
+		if len(parts) == 1 {
+			return false
+		}
+		parts = parts[:len(parts)-1]
+	}
+
+	last := parts[len(parts)-1]
+	if last != "" && isASCIIDigits(last) {
+		return true
+	}
+
+	_, ok := parseIPv4Number(last)
+	return ok
+}
+
+// parseIPv4 implements https://url.spec.whatwg.org/#concept-ipv4-parser.
+func parseIPv4(input string) (uint32, bool) {
+	parts := strings.Split(input, ".")
+	if parts[len(parts)-1] == "" && len(parts) > 1 {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) > 4 {
+		return 0, false
+	}
+
+	numbers := make([]uint64, 0, len(parts))
+	for _, part := range parts {
+		n, ok := parseIPv4Number(part)
+		if !ok {
+			return 0, false
+		}
+		numbers = append(numbers, n)
+	}
+
+	for _, n := range numbers[:len(numbers)-1] {
+		if n > 255 {
+			return 0, false
+		}
+	}
+
+	last := numbers[len(numbers)-1]
+	if last >= 1<<(8*(5-len(numbers))) {
+		return 0, false
+	}
+
+	ipv4 := last
+	for i, n := range numbers[:len(numbers)-1] {
+		ipv4 += n << (8 * (3 - i))
+	}
+
+	return uint32(ipv4), true //nolint:gosec // Range checked above.
+}
+
+// parseIPv4Number implements https://url.spec.whatwg.org/#ipv4-number-parser.
+func parseIPv4Number(input string) (uint64, bool) {
+	if input == "" {
+		return 0, false
+	}
+
+	radix := 10
+	switch {
+	case len(input) >= 2 && (input[:2] == "0x" || input[:2] == "0X"):
+		input = input[2:]
+		radix = 16
+	case len(input) >= 2 && input[0] == '0':
+		input = input[1:]
+		radix = 8
+	}
+
+	if input == "" {
+		return 0, true
+	}
+
+	n, err := strconv.ParseUint(input, radix, 64)
+	if err != nil {
+		// Anything made only of valid digits that still fails overflowed, which
+		// the spec treats as a number too large for any IPv4 part.
+		if isDigitsInRadix(input, radix) {
+			return 1 << 32, true
+		}
+		return 0, false
+	}
+
+	return n, true
+}
+
+// isDigitsInRadix reports whether every byte of s is a digit in radix.
+func isDigitsInRadix(s string, radix int) bool {
+	for i := 0; i < len(s); i++ {
+		if unhex(s[i]) < 0 || unhex(s[i]) >= radix {
+			return false
+		}
+	}
+	return true
+}
+
+// isASCIIDigits reports whether s only contains ASCII digits.
+func isASCIIDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// reportIPv4ValidationErrors reports the validation errors of input, an IPv4
+// address parseIPv4 accepted.
+func reportIPv4ValidationErrors(input string, report func(string)) {
+	parts := strings.Split(input, ".")
+	if parts[len(parts)-1] == "" && len(parts) > 1 {
+		report("IPv4-empty-part")
+		parts = parts[:len(parts)-1]
+	}
+	for _, part := range parts {
+		if len(part) >= 2 && part[0] == '0' {
+			report("IPv4-non-decimal-part")
+			return
+		}
+	}
+}
+
+// parseIPv6 implements https://url.spec.whatwg.org/#concept-ipv6-parser.
+//
+//nolint:cyclop,gocognit,funlen // Mirrors the spec algorithm step by step.
+func parseIPv6(input string) ([8]uint16, bool) {
+	var address [8]uint16
+	pieceIndex := 0
+	compress := -1
+	pointer := 0
+
+	at := func(i int) int {
+		if i < len(input) {
+			return int(input[i])
+		}
+		return -1
+	}
+
+	if at(pointer) == ':' {
+		if at(pointer+1) != ':' {
+			return address, false
+		}
+		pointer += 2
+		pieceIndex++
+		compress = pieceIndex
+	}
+
+	for at(pointer) != -1 {
+		if pieceIndex == 8 {
+			return address, false
+		}
+
+		if at(pointer) == ':' {
+			if compress != -1 {
+				return address, false
+			}
+			pointer++
+			pieceIndex++
+			compress = pieceIndex
+			continue
+		}
+
+		value, length := 0, 0
+		for length < 4 && at(pointer) != -1 && unhex(input[pointer]) >= 0 {
+			value = value*0x10 + unhex(input[pointer])
+			pointer++
+			length++
+		}
+
+		if at(pointer) == '.' {
+			if length == 0 {
+				return address, false
+			}
+			pointer -= length
+			if pieceIndex > 6 {
+				return address, false
+			}
+
+			numbersSeen := 0
+			for at(pointer) != -1 {
+				ipv4Piece := -1
+				if numbersSeen > 0 {
+					if at(pointer) != '.' || numbersSeen >= 4 {
+						return address, false
+					}
+					pointer++
+				}
+				if at(pointer) == -1 || input[pointer] < '0' || input[pointer] > '9' {
+					return address, false
+				}
+				for at(pointer) != -1 && input[pointer] >= '0' && input[pointer] <= '9' {
+					number := int(input[pointer] - '0')
+					switch ipv4Piece {
+					case -1:
+						ipv4Piece = number
+					case 0:
+						return address, false
+					default:
+						ipv4Piece = ipv4Piece*10 + number
+					}
+					if ipv4Piece > 255 {
+						return address, false
+					}
+					pointer++
+				}
+				address[pieceIndex] = address[pieceIndex]*0x100 + uint16(ipv4Piece) //nolint:gosec // At most 255.
+				numbersSeen++
+				if numbersSeen == 2 || numbersSeen == 4 {
+					pieceIndex++
+				}
+			}
+			if numbersSeen != 4 {
+				return address, false
+			}
+			break
+		}
+
+		if at(pointer) == ':' {
+			pointer++
+			if at(pointer) == -1 {
+				return address, false
+			}
+		} else if at(pointer) != -1 {
+			return address, false
+		}
+
+		address[pieceIndex] = uint16(value) //nolint:gosec // At most four hex digits.
+		pieceIndex++
+	}
+
+	if compress != -1 {
+		swaps := pieceIndex - compress
+		pieceIndex = 7
+		for pieceIndex != 0 && swaps > 0 {
+			address[pieceIndex], address[compress+swaps-1] = address[compress+swaps-1], address[pieceIndex]
+			pieceIndex--
+			swaps--
+		}
+	} else if pieceIndex != 8 {
+		return address, false
+	}
+
+	return address, true
+}
+
+// serializeIPv4 implements https://url.spec.whatwg.org/#concept-ipv4-serializer.
+func serializeIPv4(addr uint32) string {
+	parts := make([]string, 4)
+	for i := 3; i >= 0; i-- {
+		parts[i] = strconv.Itoa(int(addr % 256))
+		addr /= 256
+	}
+	return strings.Join(parts, ".")
+}
+
+// serializeIPv6 implements https://url.spec.whatwg.org/#concept-ipv6-serializer.
+func serializeIPv6(address [8]uint16) string {
+	// Find the first longest run of two or more zero pieces to compress.
+	compress, longest := -1, 1
+	for i := 0; i < 8; {
+		if address[i] != 0 {
+			i++
+			continue
+		}
+		start := i
+		for i < 8 && address[i] == 0 {
+			i++
+		}
+		if i-start > longest {
+			compress, longest = start, i-start
+		}
+	}
+
+	var b strings.Builder
+	ignore0 := false
+	for i := 0; i < 8; i++ {
+		if ignore0 && address[i] == 0 {
+			continue
+		}
+		ignore0 = false
+		if compress == i {
+			if i == 0 {
+				b.WriteString("::")
+			} else {
+				b.WriteString(":")
+			}
+			ignore0 = true
+			continue
+		}
+		b.WriteString(strconv.FormatUint(uint64(address[i]), 16))
+		if i != 7 {
+			b.WriteByte(':')
+		}
+	}
+
+	return b.String()
+}
+
+// percentDecode implements https://url.spec.whatwg.org/#string-percent-decode,
+// leaving invalid percent-encoded sequences as-is.
+func percentDecode(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			hi, lo := unhex(s[i+1]), unhex(s[i+2])
+			if hi >= 0 && lo >= 0 {
+				b.WriteByte(byte(hi<<4 | lo))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// percentEncodeC0Control percent-encodes the bytes of s in the C0 control
+// percent-encode set: C0 controls and everything above U+007E.
+func percentEncodeC0Control(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c > 0x7E {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xF])
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// unhex returns the value of a hex digit, or -1 if invalid.
+func unhex(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c - 'a' + 10)
+	case c >= 'A' && c <= 'F':
+		return int(c - 'A' + 10)
+	}
+	return -1
+}
+
+// isHexRune reports whether c is an ASCII hex digit.
+func isHexRune(c rune) bool {
+	return c >= 0 && c < utf8.RuneSelf && unhex(byte(c)) >= 0
+}
+
+// isURLCodePoint reports whether c is a URL code point
+// (https://url.spec.whatwg.org/#url-code-points).
+func isURLCodePoint(c rune) bool {
+	switch {
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'):
+		return true
+	case c < 0x80:
+		return strings.ContainsRune("!$&'()*+,-./:;=?@_~", c)
+	case c < 0xA0 || c > 0x10FFFD:
+		return false
+	case c >= 0xD800 && c <= 0xDFFF:
+		return false
+	case c >= 0xFDD0 && c <= 0xFDEF:
+		return false
+	}
+	// The last two code points of every plane are noncharacters.
+	return c&0xFFFE != 0xFFFE
+}
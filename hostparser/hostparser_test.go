@@ -0,0 +1,87 @@
+package hostparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHost(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		input     string
+		isSpecial bool
+		kind      Kind
+		want      string
+	}{
+		{name: "domain", input: "Example.COM", isSpecial: true, kind: KindDomain, want: "example.com"},
+		{name: "idna", input: "bücher.de", isSpecial: true, kind: KindDomain, want: "xn--bcher-kva.de"},
+		{name: "percent-encoded domain", input: "%65xample.com", isSpecial: true, kind: KindDomain, want: "example.com"},
+		{name: "ipv4", input: "192.168.0.1", isSpecial: true, kind: KindIPv4, want: "192.168.0.1"},
+		{name: "ipv4 shorthand", input: "0x7f.1", isSpecial: true, kind: KindIPv4, want: "127.0.0.1"},
+		{name: "ipv6", input: "[0:0:0:0:0:0:0:1]", isSpecial: true, kind: KindIPv6, want: "[::1]"},
+		{name: "opaque ipv6", input: "[::1]", isSpecial: false, kind: KindIPv6, want: "[::1]"},
+		{name: "opaque", input: "EXAMPLE", isSpecial: false, kind: KindOpaque, want: "EXAMPLE"},
+		{name: "opaque ipv4-like", input: "0x7f.1", isSpecial: false, kind: KindOpaque, want: "0x7f.1"},
+		{name: "opaque non-ascii", input: "bücher", isSpecial: false, kind: KindOpaque, want: "b%C3%BCcher"},
+		{name: "opaque empty", input: "", isSpecial: false, kind: KindEmpty, want: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			host, err := ParseHost(tc.input, tc.isSpecial)
+			require.NoError(t, err)
+			require.Equal(t, tc.kind, host.Kind)
+			require.Equal(t, tc.want, host.String())
+		})
+	}
+
+	for _, input := range []string{"", "ex ample.com", "a<b", "[::1", "1.2.3.256", "foo.09", "xn--iñvalid.com"} {
+		_, err := ParseHost(input, true)
+		require.ErrorIs(t, err, ErrInvalidHost, "input %q", input)
+	}
+	for _, input := range []string{"ex ample", "a<b", "[::1"} {
+		_, err := ParseHost(input, false)
+		require.ErrorIs(t, err, ErrInvalidHost, "input %q", input)
+	}
+}
+
+func TestParseHostWithOptionsValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input     string
+		isSpecial bool
+		want      []string
+	}{
+		{input: "127.0.0.1", isSpecial: true, want: nil},
+		{input: "0x7f.1", isSpecial: true, want: []string{"IPv4-non-decimal-part"}},
+		{input: "127.0.0.1.", isSpecial: true, want: []string{"IPv4-empty-part"}},
+		{input: "a%zzb", isSpecial: false, want: []string{"invalid-URL-unit"}},
+	}
+
+	for _, tc := range testCases {
+		var got []string
+		_, err := ParseHostWithOptions(tc.input, tc.isSpecial, Options{OnValidationError: func(errorType string) {
+			got = append(got, errorType)
+		}})
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.want, got, tc.input)
+	}
+}
+
+func TestDomainToUnicode(t *testing.T) {
+	t.Parallel()
+
+	domain, ok := DomainToUnicode("xn--bcher-kva.de")
+	require.True(t, ok)
+	require.Equal(t, "bücher.de", domain)
+
+	ascii, ok := DomainToASCII("Bücher.DE")
+	require.True(t, ok)
+	require.Equal(t, "xn--bcher-kva.de", ascii)
+}
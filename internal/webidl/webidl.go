@@ -0,0 +1,222 @@
+// Package webidl implements the WebIDL conversions
+// (https://webidl.spec.whatwg.org/#js-type-mapping) the URL and
+// URLSearchParams bindings apply to their JavaScript arguments.
+//
+// Conversions WebIDL rejects return a *TypeError for the binding to throw.
+// Exceptions thrown by JavaScript code the conversions call, such as an
+// iterator's next method or a getter, are returned as *sobek.Exception
+// errors, except those thrown by toString methods, which propagate as sobek
+// panics.
+package webidl
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/grafana/sobek"
+)
+
+// TypeError is returned for values WebIDL cannot convert to the requested
+// type. Bindings throw it as a JavaScript TypeError.
+type TypeError struct {
+	Message string
+}
+
+// Error returns the message of the TypeError.
+func (e *TypeError) Error() string {
+	return e.Message
+}
+
+// newTypeError returns a *TypeError with the given message.
+func newTypeError(message string) *TypeError {
+	return &TypeError{Message: message}
+}
+
+// ToUSVString converts v to a USVString
+// (https://webidl.spec.whatwg.org/#js-USVString): JavaScript's ToString,
+// which calls the toString or valueOf methods of objects, followed by the
+// replacement of lone surrogates with U+FFFD. Symbols are rejected.
+func ToUSVString(v sobek.Value) (string, error) {
+	if _, ok := v.(*sobek.Symbol); ok {
+		return "", newTypeError("Cannot convert a Symbol value to a string")
+	}
+	// Go strings cannot hold lone surrogates, so sobek already replaces
+	// them with U+FFFD.
+	return v.String(), nil
+}
+
+// IteratorMethod returns the @@iterator method of obj, or nil when it has
+// none, which is how WebIDL tells sequences from records apart.
+func IteratorMethod(obj *sobek.Object) (sobek.Callable, error) {
+	method := obj.GetSymbol(sobek.SymIterator)
+	if method == nil || sobek.IsUndefined(method) || sobek.IsNull(method) {
+		return nil, nil
+	}
+
+	fn, ok := sobek.AssertFunction(method)
+	if !ok {
+		return nil, newTypeError("The object's [Symbol.iterator] property is not a function")
+	}
+	return fn, nil
+}
+
+// ToPairSequence converts the iterable obj, whose @@iterator method is
+// method, to a sequence<sequence<USVString>> whose inner sequences must
+// hold exactly two items, as the URLSearchParams constructor requires.
+func ToPairSequence(obj *sobek.Object, method sobek.Callable) ([][2]string, error) {
+	var pairs [][2]string
+
+	err := iterate(obj, method, func(item sobek.Value) error {
+		itemObj, ok := item.(*sobek.Object)
+		if !ok {
+			return newTypeError("Each query pair must be an iterable [name, value] tuple")
+		}
+
+		itemMethod, err := IteratorMethod(itemObj)
+		if err != nil {
+			return err
+		}
+		if itemMethod == nil {
+			return newTypeError("Each query pair must be an iterable [name, value] tuple")
+		}
+
+		var pair []string
+		err = iterate(itemObj, itemMethod, func(v sobek.Value) error {
+			s, err := ToUSVString(v)
+			if err != nil {
+				return err
+			}
+			pair = append(pair, s)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(pair) != 2 {
+			return newTypeError("Each query pair must be an iterable [name, value] tuple")
+		}
+		pairs = append(pairs, [2]string{pair[0], pair[1]})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pairs, nil
+}
+
+// RecordConverter converts objects to records. It reads their entries
+// through the runtime's Object.entries, captured when the converter is
+// created so that scripts replacing it later cannot interfere, because
+// sobek's Go API only exposes keys in their USVString form, losing the
+// original keys holding lone surrogates.
+type RecordConverter struct {
+	entries sobek.Callable
+}
+
+// NewRecordConverter returns a RecordConverter for objects of rt.
+func NewRecordConverter(rt *sobek.Runtime) (*RecordConverter, error) {
+	object := rt.Get("Object")
+	if object == nil {
+		return nil, errors.New("the runtime has no Object constructor")
+	}
+
+	entries, ok := sobek.AssertFunction(object.ToObject(rt).Get("entries"))
+	if !ok {
+		return nil, errors.New("the runtime has no Object.entries function")
+	}
+	return &RecordConverter{entries: entries}, nil
+}
+
+// ToRecord converts obj to a record<USVString, USVString>
+// (https://webidl.spec.whatwg.org/#js-record): its own enumerable string
+// keys in property order, integer keys first, with their values, each read
+// once. When two keys convert to the same USVString, as keys holding
+// different lone surrogates do, the later value replaces the earlier one in
+// place. Own enumerable symbol keys, which come after the string ones,
+// cannot be converted and are rejected.
+func (c *RecordConverter) ToRecord(obj *sobek.Object) ([][2]string, error) {
+	entriesValue, err := c.entries(sobek.Undefined(), obj)
+	if err != nil {
+		return nil, err
+	}
+	entries, ok := entriesValue.(*sobek.Object)
+	if !ok {
+		return nil, newTypeError("Object.entries did not return an array")
+	}
+
+	var record [][2]string
+	index := make(map[string]int)
+
+	length := entries.Get("length").ToInteger()
+	for i := range length {
+		entry, ok := entries.Get(strconv.FormatInt(i, 10)).(*sobek.Object)
+		if !ok {
+			return nil, newTypeError("Object.entries did not return an array of entries")
+		}
+
+		key, err := ToUSVString(entry.Get("0"))
+		if err != nil {
+			return nil, err
+		}
+		value, err := ToUSVString(entry.Get("1"))
+		if err != nil {
+			return nil, err
+		}
+
+		if j, ok := index[key]; ok {
+			record[j][1] = value
+			continue
+		}
+		index[key] = len(record)
+		record = append(record, [2]string{key, value})
+	}
+
+	if len(obj.Symbols()) > 0 {
+		return nil, newTypeError("Cannot convert a Symbol value to a string")
+	}
+
+	return record, nil
+}
+
+// iterate runs the iterator protocol on obj through its @@iterator method,
+// calling yield with every value until the iterator is done or yield fails.
+func iterate(obj *sobek.Object, method sobek.Callable, yield func(sobek.Value) error) error {
+	iteratorValue, err := method(obj)
+	if err != nil {
+		return err
+	}
+	iterator, ok := iteratorValue.(*sobek.Object)
+	if !ok {
+		return newTypeError("Result of the Symbol.iterator method is not an object")
+	}
+
+	next, ok := sobek.AssertFunction(iterator.Get("next"))
+	if !ok {
+		return newTypeError("The iterator's next property is not a function")
+	}
+
+	for {
+		resultValue, err := next(iterator)
+		if err != nil {
+			return err
+		}
+		result, ok := resultValue.(*sobek.Object)
+		if !ok {
+			return newTypeError("Iterator result is not an object")
+		}
+
+		if done := result.Get("done"); done != nil && done.ToBoolean() {
+			return nil
+		}
+
+		value := result.Get("value")
+		if value == nil {
+			value = sobek.Undefined()
+		}
+		if err := yield(value); err != nil {
+			return err
+		}
+	}
+}
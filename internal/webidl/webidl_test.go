@@ -0,0 +1,203 @@
+package webidl
+
+import (
+	"testing"
+
+	"github.com/grafana/sobek"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToUSVString(t *testing.T) {
+	t.Parallel()
+
+	rt := sobek.New()
+	testCases := []struct {
+		script string
+		want   string
+	}{
+		{script: `"plain"`, want: "plain"},
+		{script: `42`, want: "42"},
+		{script: `null`, want: "null"},
+		{script: `undefined`, want: "undefined"},
+		{script: `({ toString() { return "object"; } })`, want: "object"},
+		{script: `"a\uD800b"`, want: "a�b"},
+	}
+
+	for _, tc := range testCases {
+		v, err := rt.RunString(tc.script)
+		require.NoError(t, err)
+
+		got, err := ToUSVString(v)
+		require.NoError(t, err, tc.script)
+		require.Equal(t, tc.want, got, tc.script)
+	}
+
+	v, err := rt.RunString(`Symbol("x")`)
+	require.NoError(t, err)
+	_, err = ToUSVString(v)
+	var typeErr *TypeError
+	require.ErrorAs(t, err, &typeErr)
+}
+
+func TestToPairSequence(t *testing.T) {
+	t.Parallel()
+
+	rt := sobek.New()
+	testCases := []struct {
+		script string
+		want   [][2]string
+	}{
+		{script: `[["a", "1"], ["b", 2]]`, want: [][2]string{{"a", "1"}, {"b", "2"}}},
+		{script: `[]`, want: nil},
+		{script: `new Map([["a", "1"], ["b", "2"]])`, want: [][2]string{{"a", "1"}, {"b", "2"}}},
+		{script: `[new Set(["k", "v"])]`, want: [][2]string{{"k", "v"}}},
+		{script: `(function* () { yield ["g", "1"]; })()`, want: [][2]string{{"g", "1"}}},
+	}
+
+	for _, tc := range testCases {
+		v, err := rt.RunString(tc.script)
+		require.NoError(t, err)
+		obj := v.ToObject(rt)
+
+		method, err := IteratorMethod(obj)
+		require.NoError(t, err)
+		require.NotNil(t, method)
+
+		got, err := ToPairSequence(obj, method)
+		require.NoError(t, err, tc.script)
+		require.Equal(t, tc.want, got, tc.script)
+	}
+
+	for _, script := range []string{`["xy"]`, `[["a"]]`, `[["a", "b", "c"]]`, `[1]`, `[{}]`, `[[Symbol("x"), "v"]]`} {
+		v, err := rt.RunString(script)
+		require.NoError(t, err)
+		obj := v.ToObject(rt)
+
+		method, err := IteratorMethod(obj)
+		require.NoError(t, err)
+
+		_, err = ToPairSequence(obj, method)
+		var typeErr *TypeError
+		require.ErrorAs(t, err, &typeErr, script)
+	}
+
+	v, err := rt.RunString(`({ [Symbol.iterator]() { throw new RangeError("boom"); } })`)
+	require.NoError(t, err)
+	obj := v.ToObject(rt)
+	method, err := IteratorMethod(obj)
+	require.NoError(t, err)
+	_, err = ToPairSequence(obj, method)
+	var exception *sobek.Exception
+	require.ErrorAs(t, err, &exception)
+	require.Contains(t, exception.Error(), "boom")
+}
+
+func TestIteratorMethod(t *testing.T) {
+	t.Parallel()
+
+	rt := sobek.New()
+
+	v, err := rt.RunString(`({ a: 1 })`)
+	require.NoError(t, err)
+	method, err := IteratorMethod(v.ToObject(rt))
+	require.NoError(t, err)
+	require.Nil(t, method)
+
+	v, err = rt.RunString(`({ [Symbol.iterator]: 1 })`)
+	require.NoError(t, err)
+	_, err = IteratorMethod(v.ToObject(rt))
+	var typeErr *TypeError
+	require.ErrorAs(t, err, &typeErr)
+}
+
+func TestToRecord(t *testing.T) {
+	t.Parallel()
+
+	rt := sobek.New()
+
+	v, err := rt.RunString(`
+		const proto = { inherited: "no" };
+		const obj = Object.create(proto);
+		obj.b = 1;
+		obj.a = { toString() { return "two"; } };
+		Object.defineProperty(obj, "hidden", { value: "no", enumerable: false });
+		obj["\uFFFD"] = "replacement";
+		obj["\uD800"] = "lone surrogate";
+		obj["x\uDC53"] = "1";
+		obj["x\uDC5C"] = "2";
+		obj;
+	`)
+	require.NoError(t, err)
+
+	converter, err := NewRecordConverter(rt)
+	require.NoError(t, err)
+	got, err := converter.ToRecord(v.ToObject(rt))
+	require.NoError(t, err)
+	require.Equal(t, [][2]string{{"b", "1"}, {"a", "two"}, {"�", "lone surrogate"}, {"x�", "2"}}, got)
+}
+
+func TestToRecordConversionOrder(t *testing.T) {
+	t.Parallel()
+
+	rt := sobek.New()
+	converter, err := NewRecordConverter(rt)
+	require.NoError(t, err)
+
+	v, err := rt.RunString(`
+		var reads = [];
+		const obj = { b: "1", 10: "ten", 2: "two" };
+		Object.defineProperty(obj, "a", {
+			enumerable: true,
+			get() { reads.push("a"); return { toString() { reads.push("toString"); return "got"; } }; },
+		});
+		Object.defineProperty(obj, Symbol("hidden"), { value: "no", enumerable: false });
+		obj;
+	`)
+	require.NoError(t, err)
+
+	got, err := converter.ToRecord(v.ToObject(rt))
+	require.NoError(t, err)
+	require.Equal(t, [][2]string{{"2", "two"}, {"10", "ten"}, {"b", "1"}, {"a", "got"}}, got)
+	require.Equal(t, "a,toString", rt.Get("reads").String())
+
+	v, err = rt.RunString(`
+		reads = [];
+		({ get a() { reads.push("a"); return "1"; }, [Symbol("x")]: "v" });
+	`)
+	require.NoError(t, err)
+
+	_, err = converter.ToRecord(v.ToObject(rt))
+	var typeErr *TypeError
+	require.ErrorAs(t, err, &typeErr)
+	require.Equal(t, "a", rt.Get("reads").String())
+
+	v, err = rt.RunString(`({ a: Symbol("x") })`)
+	require.NoError(t, err)
+	_, err = converter.ToRecord(v.ToObject(rt))
+	require.ErrorAs(t, err, &typeErr)
+}
+
+func TestRecordConverterIgnoresReplacedObjectEntries(t *testing.T) {
+	t.Parallel()
+
+	rt := sobek.New()
+	converter, err := NewRecordConverter(rt)
+	require.NoError(t, err)
+
+	v, err := rt.RunString(`
+		Object.entries = () => [["replaced", "yes"]];
+		({ a: "1", get b() { throw new RangeError("boom"); } });
+	`)
+	require.NoError(t, err)
+
+	_, err = converter.ToRecord(v.ToObject(rt))
+	var exception *sobek.Exception
+	require.ErrorAs(t, err, &exception)
+	require.Contains(t, exception.Error(), "boom")
+
+	v, err = rt.RunString(`({ a: "1" })`)
+	require.NoError(t, err)
+	got, err := converter.ToRecord(v.ToObject(rt))
+	require.NoError(t, err)
+	require.Equal(t, [][2]string{{"a", "1"}}, got)
+}
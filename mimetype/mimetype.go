@@ -0,0 +1,189 @@
+// Package mimetype implements the MIME type parser and serializer of the
+// WHATWG MIME Sniffing Standard (https://mimesniff.spec.whatwg.org/#mime-type-representation).
+package mimetype
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidMIMEType is returned for inputs the MIME type parser rejects.
+var ErrInvalidMIMEType = errors.New("invalid MIME type")
+
+// MIMEType is a parsed MIME type, such as "text/plain;charset=UTF-8".
+type MIMEType struct {
+	// Type and Subtype are lowercased, such as "text" and "plain".
+	Type    string
+	Subtype string
+
+	// Params holds the parameters in input order. Their names are
+	// lowercased and unique; values keep their case.
+	Params []Param
+}
+
+// Param is a MIME type parameter.
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Essence returns the type and subtype of m, such as "text/plain".
+func (m MIMEType) Essence() string {
+	return m.Type + "/" + m.Subtype
+}
+
+// Param returns the value of the parameter named name, which must be
+// lowercase, and whether m has it.
+func (m MIMEType) Param(name string) (string, bool) {
+	for _, p := range m.Params {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// String implements https://mimesniff.spec.whatwg.org/#serialize-a-mime-type.
+func (m MIMEType) String() string {
+	var b strings.Builder
+	b.WriteString(m.Essence())
+	for _, p := range m.Params {
+		b.WriteByte(';')
+		b.WriteString(p.Name)
+		b.WriteByte('=')
+		if p.Value != "" && isToken(p.Value) {
+			b.WriteString(p.Value)
+			continue
+		}
+		b.WriteByte('"')
+		for _, c := range p.Value {
+			if c == '"' || c == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(c)
+		}
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// Parse implements https://mimesniff.spec.whatwg.org/#parse-a-mime-type.
+func Parse(input string) (MIMEType, error) {
+	s := []rune(strings.Trim(input, httpWhitespace))
+	pos := 0
+
+	typ := collect(s, &pos, func(c rune) bool { return c != '/' })
+	if typ == "" || !isToken(typ) || pos >= len(s) {
+		return MIMEType{}, ErrInvalidMIMEType
+	}
+	pos++
+
+	subtype := collect(s, &pos, func(c rune) bool { return c != ';' })
+	subtype = strings.TrimRight(subtype, httpWhitespace)
+	if subtype == "" || !isToken(subtype) {
+		return MIMEType{}, ErrInvalidMIMEType
+	}
+
+	m := MIMEType{Type: strings.ToLower(typ), Subtype: strings.ToLower(subtype)}
+
+	for pos < len(s) {
+		// Skip the ";" and the whitespace that follows it.
+		pos++
+		collect(s, &pos, isHTTPWhitespace)
+
+		name := strings.ToLower(collect(s, &pos, func(c rune) bool { return c != ';' && c != '=' }))
+		if pos < len(s) {
+			if s[pos] == ';' {
+				continue
+			}
+			pos++
+		}
+		if pos >= len(s) {
+			break
+		}
+
+		var value string
+		if s[pos] == '"' {
+			value = collectQuotedString(s, &pos)
+			collect(s, &pos, func(c rune) bool { return c != ';' })
+		} else {
+			value = strings.TrimRight(collect(s, &pos, func(c rune) bool { return c != ';' }), httpWhitespace)
+			if value == "" {
+				continue
+			}
+		}
+
+		if name != "" && isToken(name) && isQuotedStringTokens(value) {
+			if _, ok := m.Param(name); !ok {
+				m.Params = append(m.Params, Param{Name: name, Value: value})
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// httpWhitespace holds the HTTP whitespace code points.
+const httpWhitespace = "\t\n\r "
+
+func isHTTPWhitespace(c rune) bool {
+	return strings.ContainsRune(httpWhitespace, c)
+}
+
+// collect returns the code points of s from pos on that match, advancing
+// pos past them.
+func collect(s []rune, pos *int, match func(rune) bool) string {
+	start := *pos
+	for *pos < len(s) && match(s[*pos]) {
+		*pos++
+	}
+	return string(s[start:*pos])
+}
+
+// collectQuotedString implements
+// https://fetch.spec.whatwg.org/#collect-an-http-quoted-string with the
+// extract-value flag set. s[*pos] is the opening quote.
+func collectQuotedString(s []rune, pos *int) string {
+	var b strings.Builder
+	*pos++
+	for {
+		b.WriteString(collect(s, pos, func(c rune) bool { return c != '"' && c != '\\' }))
+		if *pos >= len(s) {
+			break
+		}
+		quoteOrBackslash := s[*pos]
+		*pos++
+		if quoteOrBackslash != '\\' {
+			break
+		}
+		if *pos >= len(s) {
+			b.WriteByte('\\')
+			break
+		}
+		b.WriteRune(s[*pos])
+		*pos++
+	}
+	return b.String()
+}
+
+// isToken reports whether s only holds HTTP token code points.
+func isToken(s string) bool {
+	for _, c := range s {
+		isAlphanumeric := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlphanumeric && !strings.ContainsRune("!#$%&'*+-.^_`|~", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// isQuotedStringTokens reports whether s only holds HTTP quoted-string
+// token code points.
+func isQuotedStringTokens(s string) bool {
+	for _, c := range s {
+		if c != '\t' && (c < 0x20 || c == 0x7F || c > 0xFF) {
+			return false
+		}
+	}
+	return true
+}
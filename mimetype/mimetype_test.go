@@ -0,0 +1,63 @@
+package mimetype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{input: "text/html;charset=gbk", want: "text/html;charset=gbk"},
+		{input: " TEXT/HTML ; CHARSET=GBK ", want: "text/html;charset=GBK"},
+		{input: "text/html;charset=gbk;charset=windows-1255", want: "text/html;charset=gbk"},
+		{input: "text/html;charset=\"gbk\"", want: "text/html;charset=gbk"},
+		{input: "text/html;charset=\"gbk", want: "text/html;charset=gbk"},
+		{input: "text/html;charset=\"g\\\"bk\"", want: "text/html;charset=\"g\\\"bk\""},
+		{input: "text/html;charset=\"gbk\"x;foo=bar", want: "text/html;charset=gbk;foo=bar"},
+		{input: "text/html;charset=\"\"", want: "text/html;charset=\"\""},
+		{input: "text/html;charset=;foo=bar", want: "text/html;foo=bar"},
+		{input: "text/html;charset", want: "text/html"},
+		{input: "text/html;;;charset=gbk", want: "text/html;charset=gbk"},
+		{input: "text/html;charset= gbk", want: "text/html;charset=\" gbk\""},
+		{input: "text/html;char set=gbk", want: "text/html"},
+		{input: "text/html;charset=gék", want: "text/html;charset=\"gék\""},
+		{input: "text/html;charset=gĀk", want: "text/html"},
+		{input: "x/x;test=\"\\", want: "x/x;test=\"\\\\\""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := Parse(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, m.String())
+		})
+	}
+
+	for _, input := range []string{"", "text", "text/", "/html", "te xt/html", "text/ht ml", "text/html(;charset=gbk", "Ā/x"} {
+		_, err := Parse(input)
+		require.ErrorIs(t, err, ErrInvalidMIMEType, "input %q", input)
+	}
+}
+
+func TestMIMETypeParam(t *testing.T) {
+	t.Parallel()
+
+	m, err := Parse("multipart/form-data; Boundary=\"----x\"; charset=UTF-8")
+	require.NoError(t, err)
+	require.Equal(t, "multipart/form-data", m.Essence())
+
+	boundary, ok := m.Param("boundary")
+	require.True(t, ok)
+	require.Equal(t, "----x", boundary)
+
+	_, ok = m.Param("name")
+	require.False(t, ok)
+}
@@ -33,12 +33,6 @@
 //     forEach, entries, keys, values, and size
 //   - Proper bidirectional synchronization between URL.search and URL.searchParams
 //
-// # Known Limitations
-//
-//   - Blob URLs are not supported
-//   - Some WHATWG edge cases may differ (uses Go's net/url internally)
-//   - Data URLs with opaque paths may not be fully supported
-//
 // For more details, see the url subpackage documentation.
 package sobekurl
 
@@ -51,6 +45,28 @@ import (
 // URL is a re-export of url.URL for consumers such as k6 modules.
 type URL = url.URL
 
+// URLMutator is a re-export of url.URLMutator, the argument of the functions
+// passed to URL.Update.
+type URLMutator = url.URLMutator
+
+// RuntimeOptions is a re-export of url.RuntimeOptions.
+type RuntimeOptions = url.RuntimeOptions
+
+// ValidationError is a re-export of url.ValidationError, the argument of
+// RuntimeOptions.OnValidationError.
+type ValidationError = url.ValidationError
+
+// SchemeRegistry is a re-export of url.SchemeRegistry, set through
+// RuntimeOptions.Schemes.
+type SchemeRegistry = url.SchemeRegistry
+
+// Scheme is a re-export of url.Scheme.
+type Scheme = url.Scheme
+
+// SearchParamsOptions is a re-export of url.SearchParamsOptions, set through
+// RuntimeOptions.SearchParams.
+type SearchParamsOptions = url.SearchParamsOptions
+
 var (
 	// ExtractURL extracts a url.URL from a Sobek Value.
 	//nolint:gochecknoglobals // Re-exported for convenience
@@ -58,6 +74,19 @@ var (
 	// ParseURLArgument parses a URL argument from a Sobek Value.
 	//nolint:gochecknoglobals // Re-exported for convenience
 	ParseURLArgument = url.ParseURLArgument
+	// NewModuleExports builds the URL module exports without installing globals.
+	//nolint:gochecknoglobals // Re-exported for convenience
+	NewModuleExports = url.NewModuleExports
+	// ModuleLoader returns a goja_nodejs-style loader for require('url').
+	//nolint:gochecknoglobals // Re-exported for convenience
+	ModuleLoader = url.ModuleLoader
+	// NewSchemeRegistry returns an empty SchemeRegistry.
+	//nolint:gochecknoglobals // Re-exported for convenience
+	NewSchemeRegistry = url.NewSchemeRegistry
+	// RegisterDOMException installs the WebIDL DOMException constructor,
+	// unless the runtime already defines one.
+	//nolint:gochecknoglobals // Re-exported for convenience
+	RegisterDOMException = url.RegisterDOMException
 )
 
 // RegisterGlobally exposes the URL and URLSearchParams constructors
@@ -65,3 +94,9 @@ var (
 func RegisterGlobally(rt *sobek.Runtime) error {
 	return url.RegisterRuntime(rt)
 }
+
+// RegisterGloballyWithOptions is like RegisterGlobally but configures the
+// installed constructors with opts.
+func RegisterGloballyWithOptions(rt *sobek.Runtime, opts RuntimeOptions) error {
+	return url.RegisterRuntimeWithOptions(rt, opts)
+}
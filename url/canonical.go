@@ -0,0 +1,127 @@
+package url
+
+import "sort"
+
+// CanonicalizeOptions controls which differences Canonicalize erases.
+type CanonicalizeOptions struct {
+	// IgnoreFragment drops the fragment, so URLs differing only by their
+	// fragment share a canonical form.
+	IgnoreFragment bool
+
+	// IgnoreQueryOrder treats the query as an unordered list of name/value
+	// pairs, so "?a=1&b=2" and "?b=2&a=1" share a canonical form.
+	IgnoreQueryOrder bool
+}
+
+// DedupOptions controls how DedupURLs decides that two URLs are duplicates.
+type DedupOptions = CanonicalizeOptions
+
+// Canonicalize returns a canonical serialization of u suitable for
+// comparisons, without mutating u. Percent-encoded sequences are normalized
+// with NormalizeEscapes, and opts optionally drops the fragment and sorts
+// the query parameters.
+func (u *URL) Canonicalize(opts CanonicalizeOptions) string {
+	return NormalizeEscapes(canonicalHref(u, opts), EncodeSetC0Control)
+}
+
+// DedupURLs returns urls with duplicates removed, keeping the first
+// occurrence of each URL and preserving the input order.
+//
+// URLs are compared by their Canonicalize form. Strings that cannot be
+// parsed are kept and compared verbatim; nil *URL entries are dropped.
+func DedupURLs[T string | *URL](urls []T, opts DedupOptions) []T {
+	seen := make(map[dedupID]struct{}, len(urls))
+	result := make([]T, 0, len(urls))
+
+	for _, item := range urls {
+		key, ok := dedupKey(item, opts)
+		if !ok {
+			continue
+		}
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, item)
+	}
+
+	return result
+}
+
+// dedupID is the comparison key of a DedupURLs item.
+type dedupID struct {
+	key string
+
+	// invalid marks unparseable inputs, whose key is the input itself, so
+	// they never collide with a serialized URL such as "invalid:x".
+	invalid bool
+}
+
+// dedupKey computes the comparison key of a DedupURLs item. It reports false
+// for items that should be dropped from the result.
+func dedupKey[T string | *URL](item T, opts DedupOptions) (dedupID, bool) {
+	switch v := any(item).(type) {
+	case string:
+		u, err := NewURL(v, "")
+		if err != nil {
+			return dedupID{key: v, invalid: true}, true
+		}
+		return dedupID{key: u.Canonicalize(opts)}, true
+	case *URL:
+		if v == nil {
+			return dedupID{}, false
+		}
+		return dedupID{key: v.Canonicalize(opts)}, true
+	default:
+		return dedupID{}, false
+	}
+}
+
+// canonicalHref serializes u after applying opts, without mutating u.
+func canonicalHref(u *URL, opts CanonicalizeOptions) string {
+	inner := *u.inner
+
+	if opts.IgnoreQueryOrder && u.query() != "" {
+		entries := make([]urlParam, len(u.searchParams.entries))
+		copy(entries, u.searchParams.entries)
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].key != entries[j].key {
+				return compareByCodeUnits(entries[i].key, entries[j].key) < 0
+			}
+			return compareByCodeUnits(entries[i].value, entries[j].value) < 0
+		})
+		inner.query = strPtr(encodeFormEncoded(entries))
+	}
+
+	return inner.serialize(opts.IgnoreFragment)
+}
+
+// EqualsOptions tunes URL.Equals.
+type EqualsOptions struct {
+	// ExcludeFragments ignores the fragments of both URLs.
+	ExcludeFragments bool
+}
+
+// Equals implements https://url.spec.whatwg.org/#concept-url-equals: u and
+// other are equal when their serializations are. Unlike Canonicalize, no
+// normalization beyond the parser's is applied, so "?a=1&b=2" and
+// "?b=2&a=1" differ. A nil other is never equal.
+func (u *URL) Equals(other *URL, opts EqualsOptions) bool {
+	if other == nil {
+		return false
+	}
+	return u.Serialize(opts.ExcludeFragments) == other.Serialize(opts.ExcludeFragments)
+}
+
+// EqualIgnoringQueryOrder reports whether a and b are the same URL when the
+// query is treated as a multiset of decoded name/value pairs: "?a=1&b=%20"
+// equals "?b=+&a=1", but "?a=1&a=1" does not equal "?a=1". The remaining
+// components are compared by their Canonicalize form. Two nil URLs are equal.
+func EqualIgnoringQueryOrder(a, b *URL) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	opts := CanonicalizeOptions{IgnoreQueryOrder: true}
+	return a.Canonicalize(opts) == b.Canonicalize(opts)
+}
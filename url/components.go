@@ -0,0 +1,74 @@
+package url
+
+// Components is a copy of the URL record behind a URL
+// (https://url.spec.whatwg.org/#concept-url), for Go code that needs its
+// parts without reparsing the strings the getters return. Every string is
+// in its serialized, percent-encoded form.
+type Components struct {
+	Scheme   string
+	Username string
+	Password string
+
+	// Host is nil when the URL has no host, as in "mailto:x". An empty
+	// host, as in "file:///p", has the HostEmpty kind.
+	Host *Host
+
+	// Port is nil when the URL has no port or the default one of its scheme.
+	Port *uint16
+
+	// Path holds the path segments of URLs without an opaque path.
+	Path []string
+
+	// OpaquePath is non-nil for URLs with an opaque path, such as
+	// "someone@example.com" in "mailto:someone@example.com".
+	OpaquePath *string
+
+	// Query and Fragment are nil when the URL has none, and point to an
+	// empty string for URLs ending with "?" or "#".
+	Query    *string
+	Fragment *string
+}
+
+// Components returns the parsed parts of u. Mutating the result does not
+// affect u.
+func (u *URL) Components() Components {
+	r := u.inner
+	c := Components{
+		Scheme:     r.scheme,
+		Username:   r.username,
+		Password:   r.password,
+		Path:       append([]string(nil), r.path...),
+		OpaquePath: copyStrPtr(r.opaquePath),
+		Query:      copyStrPtr(r.query),
+		Fragment:   copyStrPtr(r.fragment),
+	}
+
+	if r.host != nil {
+		host := typedHost(*r.host, r.isSpecial())
+		c.Host = &host
+	}
+	if r.port != noPort {
+		port := uint16(r.port) //nolint:gosec // The parser only accepts 16-bit ports.
+		c.Port = &port
+	}
+
+	return c
+}
+
+// typedHost reparses host, a serialized host of a URL, into a Host.
+func typedHost(host string, isSpecial bool) Host {
+	if host == "" {
+		return Host{Kind: HostEmpty}
+	}
+	// Serialized hosts parse back to themselves, so this cannot fail.
+	parsed, _ := parseHost(host, !isSpecial, nil)
+	return parsed
+}
+
+// copyStrPtr returns a pointer to a copy of *s, or nil when s is nil.
+func copyStrPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	return strPtr(*s)
+}
@@ -0,0 +1,51 @@
+package url
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Messages reported through ParseOptions.OnDivergence, one per opt-in
+// behavior that differs from the WHATWG URL Standard.
+const (
+	divergenceCustomScheme = "the %q scheme follows the rules of a SchemeRegistry, " +
+		"which the WHATWG URL Standard does not define"
+	divergenceSemicolons = `query pairs are also split on ";" ` +
+		"(SearchParamsOptions.SemicolonSeparators), which the WHATWG URL Standard does not do"
+	divergenceSerialization = "query parameters are not serialized as application/x-www-form-urlencoded " +
+		"(SearchParamsOptions), unlike in the WHATWG URL Standard"
+)
+
+// reportSchemeDivergence calls report when the scheme of record gets rules
+// from its SchemeRegistry.
+func reportSchemeDivergence(record *urlRecord, report func(message string)) {
+	if report == nil {
+		return
+	}
+
+	if registered, ok := record.schemes.lookup(record.scheme); ok && (registered.Special || registered.DefaultPort != 0) {
+		report(fmt.Sprintf(divergenceCustomScheme, record.scheme))
+	}
+}
+
+// parseQuery parses the query string s into entries according to sp.opts,
+// reporting when they split it differently from the standard parser.
+func (sp *URLSearchParams) parseQuery(s string) []urlParam {
+	if sp.onDivergence != nil && sp.opts.SemicolonSeparators && strings.Contains(s, ";") {
+		sp.onDivergence(divergenceSemicolons)
+	}
+	return sp.opts.parse(s)
+}
+
+// reportSerializationDivergence reports when serialized, the serialization
+// of sp, differs from the standard one.
+func (sp *URLSearchParams) reportSerializationDivergence(serialized string) {
+	if sp.onDivergence == nil {
+		return
+	}
+
+	standard := SearchParamsOptions{SemicolonSeparators: sp.opts.SemicolonSeparators}
+	if sp.opts != standard && serialized != standard.serialize(sp.entries) {
+		sp.onDivergence(divergenceSerialization)
+	}
+}
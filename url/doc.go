@@ -12,6 +12,8 @@
 //   - Static URL.canParse() and URL.parse() methods
 //   - Proper synchronization between URL.search and URL.searchParams
 //   - URLSearchParams iteration via Symbol.iterator
+//   - The WebIDL DOMException constructor, installed by RegisterDOMException
+//     when the runtime lacks one
 //
 // # Usage
 //
@@ -22,25 +24,17 @@
 //	    log.Fatal(err)
 //	}
 //
-// # Known Limitations
-//
-//   - Blob URLs are not supported
-//   - Some edge-case Unicode/punycode behaviors may differ from browsers
-//   - Origin computation for non-standard schemes returns "null"
-//   - Base URL validation is more lenient than WHATWG (uses Go's net/url)
-//   - Data URLs with opaque paths may not be fully supported
-//   - URLSearchParams iterators are not live (don't reflect mutations during iteration)
-//
 // # Go API invariants
 //
-// The exported Go types primarily exist to back the Sobek bindings. They are
-// intentionally small wrappers around Go's net/url so that they can be used in
-// tests and, when needed, inside Grafana/k6 integrations. Always construct a
-// URL by calling NewURL (and URLSearchParams with the constructors in this
+// The exported Go types primarily exist to back the Sobek bindings. URLs are
+// parsed by an implementation of the WHATWG basic URL parser into a URL
+// record; GoURL converts them to Go's net/url when integrations need it.
+// Always construct a URL by calling NewURL (and URLSearchParams with the constructors in this
 // package); doing so guarantees that:
 //
-//   - URL.inner is non-nil and carries the parsed WHATWG representation.
-//   - URL.searchParams is non-nil and bidirectionally synced with URL.inner.
+//   - URL.inner is non-nil and carries the parsed WHATWG URL record.
+//   - URL.searchParams is non-nil and bidirectionally synced with the query
+//     of URL.inner.
 //   - URLSearchParams.owner is either nil or points to the URL that must be
 //     kept in sync.
 //
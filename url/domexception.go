@@ -0,0 +1,187 @@
+package url
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+)
+
+// domExceptionCodes lists the legacy error code constants of DOMException
+// (https://webidl.spec.whatwg.org/#dfn-error-names-table), in the order in
+// which they are defined on the interface and its prototype.
+//
+//nolint:gochecknoglobals // Read-only lookup table.
+var domExceptionCodes = []struct {
+	constant string
+	name     string
+	code     int
+}{
+	{constant: "INDEX_SIZE_ERR", name: "IndexSizeError", code: 1},
+	{constant: "DOMSTRING_SIZE_ERR", code: 2},
+	{constant: "HIERARCHY_REQUEST_ERR", name: "HierarchyRequestError", code: 3},
+	{constant: "WRONG_DOCUMENT_ERR", name: "WrongDocumentError", code: 4},
+	{constant: "INVALID_CHARACTER_ERR", name: "InvalidCharacterError", code: 5},
+	{constant: "NO_DATA_ALLOWED_ERR", code: 6},
+	{constant: "NO_MODIFICATION_ALLOWED_ERR", name: "NoModificationAllowedError", code: 7},
+	{constant: "NOT_FOUND_ERR", name: "NotFoundError", code: 8},
+	{constant: "NOT_SUPPORTED_ERR", name: "NotSupportedError", code: 9},
+	{constant: "INUSE_ATTRIBUTE_ERR", name: "InUseAttributeError", code: 10},
+	{constant: "INVALID_STATE_ERR", name: "InvalidStateError", code: 11},
+	{constant: "SYNTAX_ERR", name: "SyntaxError", code: 12},
+	{constant: "INVALID_MODIFICATION_ERR", name: "InvalidModificationError", code: 13},
+	{constant: "NAMESPACE_ERR", name: "NamespaceError", code: 14},
+	{constant: "INVALID_ACCESS_ERR", name: "InvalidAccessError", code: 15},
+	{constant: "VALIDATION_ERR", code: 16},
+	{constant: "TYPE_MISMATCH_ERR", name: "TypeMismatchError", code: 17},
+	{constant: "SECURITY_ERR", name: "SecurityError", code: 18},
+	{constant: "NETWORK_ERR", name: "NetworkError", code: 19},
+	{constant: "ABORT_ERR", name: "AbortError", code: 20},
+	{constant: "URL_MISMATCH_ERR", name: "URLMismatchError", code: 21},
+	{constant: "QUOTA_EXCEEDED_ERR", name: "QuotaExceededError", code: 22},
+	{constant: "TIMEOUT_ERR", name: "TimeoutError", code: 23},
+	{constant: "INVALID_NODE_TYPE_ERR", name: "InvalidNodeTypeError", code: 24},
+	{constant: "DATA_CLONE_ERR", name: "DataCloneError", code: 25},
+}
+
+// domExceptionSlot keys the hidden property holding the internal slots of
+// DOMException instances, which the prototype's getters brand check.
+//
+//nolint:gochecknoglobals // A symbol is an identity, shared by every runtime.
+var domExceptionSlot = sobek.NewSymbol("DOMException")
+
+// domExceptionInternals is the value of the domExceptionSlot property.
+type domExceptionInternals struct {
+	// owner is the instance the slots belong to, so that objects
+	// inheriting from it or given a copy of the property are not branded.
+	owner *sobek.Object
+
+	name    string
+	message string
+}
+
+// code returns the legacy code of the exception's name, or 0 for names
+// that have none.
+func (e *domExceptionInternals) code() int {
+	for _, entry := range domExceptionCodes {
+		if entry.name != "" && entry.name == e.name {
+			return entry.code
+		}
+	}
+	return 0
+}
+
+// RegisterDOMException installs the DOMException interface of WebIDL
+// (https://webidl.spec.whatwg.org/#idl-DOMException) as a global of rt,
+// unless the host already provides one. RegisterRuntime leaves it out, so
+// that embedders with their own DOMException can install it later.
+func RegisterDOMException(rt *sobek.Runtime) error {
+	if existing := rt.Get("DOMException"); !isNullish(existing) {
+		return nil
+	}
+
+	constructor, err := newDOMExceptionConstructor(rt)
+	if err != nil {
+		return err
+	}
+
+	if err := rt.GlobalObject().DefineDataProperty("DOMException", constructor,
+		sobek.FLAG_TRUE, sobek.FLAG_TRUE, sobek.FLAG_FALSE); err != nil {
+		return fmt.Errorf("setting DOMException constructor: %w", err)
+	}
+	return nil
+}
+
+// newDOMExceptionConstructor builds the DOMException constructor: its
+// instances inherit from Error.prototype, and their name, message and code
+// are read through brand-checked prototype getters.
+func newDOMExceptionConstructor(rt *sobek.Runtime) (*sobek.Object, error) {
+	constructor := func(call sobek.ConstructorCall) *sobek.Object {
+		internals := &domExceptionInternals{owner: call.This, name: "Error"}
+		if message := call.Argument(0); !sobek.IsUndefined(message) {
+			internals.message = toUSVString(rt, message)
+		}
+		if name := call.Argument(1); !sobek.IsUndefined(name) {
+			internals.name = toUSVString(rt, name)
+		}
+
+		if err := call.This.DefineDataPropertySymbol(domExceptionSlot, rt.ToValue(internals),
+			sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE); err != nil {
+			panic(rt.NewGoError(fmt.Errorf("defining the DOMException slot: %w", err)))
+		}
+		return nil
+	}
+
+	ctor := rt.ToValue(constructor).ToObject(rt)
+	if err := ctor.DefineDataProperty("name", rt.ToValue("DOMException"),
+		sobek.FLAG_FALSE, sobek.FLAG_TRUE, sobek.FLAG_FALSE); err != nil {
+		return nil, fmt.Errorf("setting DOMException.name: %w", err)
+	}
+
+	proto := ctor.Get("prototype").ToObject(rt)
+	if err := ctor.DefineDataProperty("prototype", proto,
+		sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE); err != nil {
+		return nil, fmt.Errorf("setting DOMException.prototype: %w", err)
+	}
+	if err := proto.SetPrototype(rt.Get("Error").ToObject(rt).Get("prototype").ToObject(rt)); err != nil {
+		return nil, fmt.Errorf("inheriting from Error.prototype: %w", err)
+	}
+
+	getters := []struct {
+		name string
+		get  func(e *domExceptionInternals) sobek.Value
+	}{
+		{name: "name", get: func(e *domExceptionInternals) sobek.Value { return rt.ToValue(e.name) }},
+		{name: "message", get: func(e *domExceptionInternals) sobek.Value { return rt.ToValue(e.message) }},
+		{name: "code", get: func(e *domExceptionInternals) sobek.Value { return rt.ToValue(e.code()) }},
+	}
+	for _, getter := range getters {
+		get := getter.get
+		name := getter.name
+		getterFunc := func(call sobek.FunctionCall) sobek.Value {
+			internals, ok := unwrapDOMException(call.This)
+			if !ok {
+				throwAsJSError(rt, NewError(TypeError, "Illegal invocation: DOMException."+name+" getter called on an incompatible receiver"))
+			}
+			return get(internals)
+		}
+		if err := proto.DefineAccessorProperty(name, rt.ToValue(getterFunc), nil,
+			sobek.FLAG_TRUE, sobek.FLAG_TRUE); err != nil {
+			return nil, fmt.Errorf("defining DOMException.prototype.%s: %w", name, err)
+		}
+	}
+
+	// The constants are defined on both the interface and its prototype.
+	for _, entry := range domExceptionCodes {
+		for _, target := range []*sobek.Object{ctor, proto} {
+			if err := target.DefineDataProperty(entry.constant, rt.ToValue(entry.code),
+				sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE); err != nil {
+				return nil, fmt.Errorf("defining DOMException.%s: %w", entry.constant, err)
+			}
+		}
+	}
+
+	if err := proto.DefineDataPropertySymbol(sobek.SymToStringTag, rt.ToValue("DOMException"),
+		sobek.FLAG_FALSE, sobek.FLAG_TRUE, sobek.FLAG_FALSE); err != nil {
+		return nil, fmt.Errorf("defining DOMException.prototype[Symbol.toStringTag]: %w", err)
+	}
+
+	return ctor, nil
+}
+
+// unwrapDOMException returns the internal slots of v if it is a DOMException
+// instance.
+func unwrapDOMException(v sobek.Value) (*domExceptionInternals, bool) {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	slot := obj.GetSymbol(domExceptionSlot)
+	if slot == nil {
+		return nil, false
+	}
+	internals, ok := slot.Export().(*domExceptionInternals)
+	if !ok || internals.owner != obj {
+		return nil, false
+	}
+	return internals, true
+}
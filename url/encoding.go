@@ -0,0 +1,173 @@
+package url
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// EncodeSet identifies one of the WHATWG percent-encode sets
+// (https://url.spec.whatwg.org/#percent-encoded-bytes). Each set is a
+// superset of the previous one, except SpecialQuery which only extends Query.
+type EncodeSet int
+
+const (
+	// EncodeSetC0Control contains C0 controls and all bytes above U+007E.
+	EncodeSetC0Control EncodeSet = iota
+	// EncodeSetFragment adds space, ", <, > and ` to the C0 control set.
+	EncodeSetFragment
+	// EncodeSetQuery adds space, ", #, < and > to the C0 control set.
+	EncodeSetQuery
+	// EncodeSetSpecialQuery adds ' to the query set, for special schemes.
+	EncodeSetSpecialQuery
+	// EncodeSetPath adds ?, ^, `, { and } to the query set.
+	EncodeSetPath
+	// EncodeSetUserinfo adds /, :, ;, =, @, [ to ^ and | to the path set.
+	EncodeSetUserinfo
+	// EncodeSetComponent adds $ to &, + and , to the userinfo set.
+	EncodeSetComponent
+	// EncodeSetFormURLEncoded adds !, ' to ) and ~ to the component set.
+	EncodeSetFormURLEncoded
+)
+
+// contains reports whether c must be percent-encoded in set s.
+func (s EncodeSet) contains(c byte) bool {
+	if c <= 0x1F || c > 0x7E {
+		return true
+	}
+
+	switch s {
+	case EncodeSetC0Control:
+		return false
+	case EncodeSetFragment:
+		return strings.IndexByte(" \"<>`", c) >= 0
+	case EncodeSetQuery:
+		return strings.IndexByte(" \"#<>", c) >= 0
+	case EncodeSetSpecialQuery:
+		return strings.IndexByte(" \"#<>'", c) >= 0
+	case EncodeSetPath:
+		return strings.IndexByte(" \"#<>?^`{}", c) >= 0
+	case EncodeSetUserinfo:
+		return strings.IndexByte(" \"#<>?^`{}/:;=@[\\]|", c) >= 0
+	case EncodeSetComponent:
+		return strings.IndexByte(" \"#<>?^`{}/:;=@[\\]|$%&+,", c) >= 0
+	default:
+		return strings.IndexByte(" \"#<>?^`{}/:;=@[\\]|$%&+,!'()~", c) >= 0
+	}
+}
+
+// NormalizeEscapes normalizes the percent-encoded sequences of s: hex digits
+// are uppercased and escapes of unreserved characters (ASCII alphanumerics,
+// "-", ".", "_" and "~") that set does not require to be encoded are
+// decoded, so "%7e" and "~" compare equal. Invalid escapes are kept as-is.
+func NormalizeEscapes(s string, set EncodeSet) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			hi, lo := unhex(s[i+1]), unhex(s[i+2])
+			if hi >= 0 && lo >= 0 {
+				c := byte(hi<<4 | lo)
+				if isUnreserved(c) && !set.contains(c) {
+					b.WriteByte(c)
+				} else {
+					b.WriteByte('%')
+					b.WriteByte(hexDigit(c >> 4))
+					b.WriteByte(hexDigit(c & 0x0F))
+				}
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+// isUnreserved reports whether c is an RFC 3986 unreserved character.
+func isUnreserved(c byte) bool {
+	return isASCIIAlpha(c) || (c >= '0' && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// percentEncodeString UTF-8 percent-encodes every code point of s that set
+// contains (https://url.spec.whatwg.org/#string-utf-8-percent-encode).
+func percentEncodeString(s string, set EncodeSet) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		appendPercentEncoded(&b, r, set)
+	}
+	return b.String()
+}
+
+// appendPercentEncoded writes c to b, UTF-8 percent-encoding it when set
+// contains it (https://url.spec.whatwg.org/#utf-8-percent-encode).
+func appendPercentEncoded(b *strings.Builder, c rune, set EncodeSet) {
+	if c < utf8.RuneSelf && !set.contains(byte(c)) {
+		b.WriteByte(byte(c))
+		return
+	}
+
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], c)
+	for _, octet := range buf[:n] {
+		b.WriteByte('%')
+		b.WriteByte(hexDigit(octet >> 4))
+		b.WriteByte(hexDigit(octet & 0x0F))
+	}
+}
+
+// isUTF8 reports whether enc is nil or UTF-8, in which case the encoding
+// override is a no-op.
+func isUTF8(enc encoding.Encoding) bool {
+	return enc == nil || enc == unicode.UTF8
+}
+
+// percentEncodeAfterEncoding implements
+// https://url.spec.whatwg.org/#string-percent-encode-after-encoding: each
+// code point of s is encoded with enc, the bytes set contains are
+// percent-encoded and code points enc cannot represent become a
+// percent-encoded HTML numeric character reference ("%26%23" N "%3B"). Spaces
+// become "+" when spaceAsPlus is set.
+func percentEncodeAfterEncoding(enc encoding.Encoding, s string, set EncodeSet, spaceAsPlus bool) string {
+	encoder := enc.NewEncoder()
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	var buf [utf8.UTFMax]byte
+	for _, r := range s {
+		n := utf8.EncodeRune(buf[:], r)
+		encoded, err := encoder.Bytes(buf[:n])
+		if err != nil {
+			b.WriteString("%26%23")
+			b.WriteString(strconv.Itoa(int(r)))
+			b.WriteString("%3B")
+			continue
+		}
+
+		for _, octet := range encoded {
+			switch {
+			case spaceAsPlus && octet == ' ':
+				b.WriteByte('+')
+			case set.contains(octet):
+				b.WriteByte('%')
+				b.WriteByte(hexDigit(octet >> 4))
+				b.WriteByte(hexDigit(octet & 0x0F))
+			default:
+				b.WriteByte(octet)
+			}
+		}
+	}
+
+	return b.String()
+}
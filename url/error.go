@@ -13,6 +13,10 @@ const (
 	// TypeError is thrown when URL parsing fails or invalid
 	// operations are attempted.
 	TypeError ErrorName = "TypeError"
+
+	// RangeError is returned when an index or numeric argument falls
+	// outside the accepted range.
+	RangeError ErrorName = "RangeError"
 )
 
 // Error represents a URL-related error that can be converted to a JS exception.
@@ -31,6 +35,8 @@ func (e *Error) JSError(rt *sobek.Runtime) *sobek.Object {
 	switch e.Name {
 	case TypeError:
 		constructor = rt.Get("TypeError").ToObject(rt)
+	case RangeError:
+		constructor = rt.Get("RangeError").ToObject(rt)
 	default:
 		constructor = rt.Get("Error").ToObject(rt)
 	}
@@ -0,0 +1,61 @@
+package url
+
+import "github.com/oleiade/sobek-webapi-url/hostparser"
+
+// HostKind identifies which kind of host a Host value holds.
+type HostKind = hostparser.Kind
+
+const (
+	// HostDomain is an ASCII domain such as "example.com".
+	HostDomain = hostparser.KindDomain
+	// HostIPv4 is an IPv4 address such as "127.0.0.1".
+	HostIPv4 = hostparser.KindIPv4
+	// HostIPv6 is an IPv6 address such as "[::1]".
+	HostIPv6 = hostparser.KindIPv6
+	// HostOpaque is the host of a non-special URL, such as "EXAMPLE" in
+	// "foo://EXAMPLE/", kept as-is apart from percent-encoding.
+	HostOpaque = hostparser.KindOpaque
+	// HostEmpty is the empty host of URLs such as "file:///tmp".
+	HostEmpty = hostparser.KindEmpty
+)
+
+// Host is a parsed WHATWG host (https://url.spec.whatwg.org/#concept-host).
+type Host = hostparser.Host
+
+// ParseHost parses s with the WHATWG host parser for special schemes. It
+// detects IPv4 and IPv6 addresses, applies IDNA processing to domains and
+// rejects forbidden code points.
+func ParseHost(s string) (Host, error) {
+	return parseHost(s, false, nil)
+}
+
+// parseHost runs the host parser of the hostparser package, turning its
+// failures into TypeErrors. isOpaque is set for the hosts of non-special
+// URLs. report, when non-nil, receives the validation errors the parser
+// recovers from.
+func parseHost(input string, isOpaque bool, report func(ValidationErrorType)) (Host, error) {
+	var opts hostparser.Options
+	if report != nil {
+		opts.OnValidationError = func(errorType string) {
+			report(ValidationErrorType(errorType))
+		}
+	}
+
+	host, err := hostparser.ParseHostWithOptions(input, !isOpaque, opts)
+	if err != nil {
+		return Host{}, invalidHostError()
+	}
+	return host, nil
+}
+
+// IsValidHostname reports whether s is a valid host for special schemes
+// such as http and https.
+func IsValidHostname(s string) bool {
+	_, err := ParseHost(s)
+	return err == nil
+}
+
+// invalidHostError allocates a TypeError for hosts the host parser rejects.
+func invalidHostError() *Error {
+	return NewError(TypeError, "Invalid host")
+}
@@ -0,0 +1,162 @@
+package url
+
+import "strings"
+
+// defaultLooseScheme is the scheme ParseLoose adds when the input has none.
+const defaultLooseScheme = "https"
+
+// LooseOptions configures the fixups ParseLoose applies before parsing.
+type LooseOptions struct {
+	// DefaultScheme is prepended to inputs that lack a scheme, such as
+	// "example.com/path". It defaults to "https".
+	DefaultScheme string
+
+	// FixSchemeTypos repairs common scheme typos such as "ttp://",
+	// "htps://" or "http//".
+	FixSchemeTypos bool
+}
+
+// schemeTypoFixes maps mistyped scheme prefixes (lowercased) to their fix.
+//
+//nolint:gochecknoglobals // Read-only lookup table.
+var schemeTypoFixes = []struct {
+	typo string
+	fix  string
+}{
+	{typo: "ttps://", fix: "https://"},
+	{typo: "ttp://", fix: "http://"},
+	{typo: "htps://", fix: "https://"},
+	{typo: "htp://", fix: "http://"},
+	{typo: "hhttps://", fix: "https://"},
+	{typo: "hhttp://", fix: "http://"},
+	{typo: "https//", fix: "https://"},
+	{typo: "http//", fix: "http://"},
+	{typo: "https:/", fix: "https://"},
+	{typo: "http:/", fix: "http://"},
+}
+
+// ParseLoose parses input after applying address-bar-style fixups, for URLs
+// copied from spreadsheets, logs or chat messages.
+//
+// It trims surrounding whitespace, wrapping quotes and brackets, and
+// trailing punctuation; optionally repairs scheme typos; and prepends
+// opts.DefaultScheme when the input has no scheme. The result is then
+// parsed with NewURL, so it still fails on inputs that are not URLs.
+func ParseLoose(input string, opts LooseOptions) (*URL, error) {
+	s := trimLooseInput(input)
+	if s == "" {
+		return nil, invalidURLError()
+	}
+
+	if opts.FixSchemeTypos {
+		s = fixSchemeTypo(s)
+	}
+
+	if !hasLooseScheme(s) {
+		scheme := opts.DefaultScheme
+		if scheme == "" {
+			scheme = defaultLooseScheme
+		}
+		s = scheme + "://" + strings.TrimPrefix(s, "//")
+	}
+
+	return NewURL(s, "")
+}
+
+// trimLooseInput repeatedly strips whitespace, wrapping pairs such as
+// <...> or "...", trailing punctuation and unbalanced closing brackets.
+func trimLooseInput(s string) string {
+	for {
+		trimmed := strings.TrimSpace(s)
+		if trimmed == "" {
+			return trimmed
+		}
+
+		first, last := trimmed[0], trimmed[len(trimmed)-1]
+		switch {
+		case len(trimmed) >= 2 && isWrappingPair(first, last):
+			trimmed = trimmed[1 : len(trimmed)-1]
+		case strings.IndexByte(".,;:!?", last) >= 0:
+			trimmed = trimmed[:len(trimmed)-1]
+		case last == ')' && strings.Count(trimmed, "(") < strings.Count(trimmed, ")"),
+			last == ']' && strings.Count(trimmed, "[") < strings.Count(trimmed, "]"),
+			last == '>' && strings.Count(trimmed, "<") < strings.Count(trimmed, ">"):
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+
+		if trimmed == s {
+			return s
+		}
+		s = trimmed
+	}
+}
+
+// isWrappingPair reports whether first and last delimit a quoted or
+// bracketed input.
+func isWrappingPair(first, last byte) bool {
+	switch first {
+	case '<':
+		return last == '>'
+	case '(':
+		return last == ')'
+	case '[':
+		return last == ']'
+	case '"', '\'', '`':
+		return last == first
+	}
+	return false
+}
+
+// fixSchemeTypo replaces a known mistyped scheme prefix of s.
+func fixSchemeTypo(s string) string {
+	lower := strings.ToLower(s)
+	for _, f := range schemeTypoFixes {
+		if strings.HasPrefix(lower, f.typo) {
+			// "http:/" must not match an already valid "http://".
+			if strings.HasPrefix(lower, f.fix) {
+				return s
+			}
+			return f.fix + s[len(f.typo):]
+		}
+	}
+	return s
+}
+
+// hasLooseScheme reports whether s starts with something that should be
+// treated as a scheme. Inputs such as "example.com:8080" or "localhost:3000"
+// are host/port pairs rather than schemes.
+func hasLooseScheme(s string) bool {
+	colon := strings.IndexByte(s, ':')
+	if colon <= 0 || !isValidScheme(s[:colon]) {
+		return false
+	}
+
+	rest := s[colon+1:]
+	if strings.HasPrefix(rest, "/") {
+		return true
+	}
+	if strings.Contains(s[:colon], ".") {
+		return false
+	}
+	return rest == "" || rest[0] < '0' || rest[0] > '9'
+}
+
+// isValidScheme reports whether s matches the WHATWG scheme syntax: an ASCII
+// alpha followed by ASCII alphanumerics, "+", "-" or ".".
+func isValidScheme(s string) bool {
+	if s == "" || !isASCIIAlpha(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if !isASCIIAlpha(c) && (c < '0' || c > '9') && c != '+' && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// isASCIIAlpha reports whether c is an ASCII letter.
+func isASCIIAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,76 @@
+package url
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/oleiade/sobek-webapi-url/hostparser"
+)
+
+// ModuleExports holds the values a module system exposes for the URL API.
+// It mirrors the shape of k6's modules.Exports, so k6-style modules can
+// return it from their Exports method.
+type ModuleExports struct {
+	// Default is the default export: an object carrying every named export,
+	// as Node's require('url') returns.
+	Default any
+
+	// Named holds the named exports: URL, URLSearchParams, and the
+	// Node-compatible domainToASCII and domainToUnicode helpers.
+	Named map[string]any
+}
+
+// NewModuleExports builds the URL module exports for rt without installing
+// any global. The constructors are configured with opts.
+func NewModuleExports(rt *sobek.Runtime, opts RuntimeOptions) (*ModuleExports, error) {
+	urlConstructor, searchParamsConstructor, err := newConstructors(rt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	named := map[string]any{
+		"URL":             urlConstructor,
+		"URLSearchParams": searchParamsConstructor,
+		"domainToASCII": func(domain string) string {
+			host, err := ParseHost(domain)
+			if err != nil {
+				return ""
+			}
+			return host.String()
+		},
+		"domainToUnicode": func(domain string) string {
+			unicode, ok := hostparser.DomainToUnicode(domain)
+			if !ok {
+				return ""
+			}
+			return unicode
+		},
+	}
+
+	defaultExport := rt.NewObject()
+	for name, value := range named {
+		if err := defaultExport.Set(name, value); err != nil {
+			return nil, fmt.Errorf("setting %s export: %w", name, err)
+		}
+	}
+
+	return &ModuleExports{Default: defaultExport, Named: named}, nil
+}
+
+// ModuleLoader returns a loader with the goja_nodejs require.ModuleLoader
+// signature, so the API can be registered as a native module and loaded
+// with require('url'). The loader replaces module.exports with the default
+// export and panics if the exports cannot be built, as loaders do.
+func ModuleLoader(opts RuntimeOptions) func(rt *sobek.Runtime, module *sobek.Object) {
+	return func(rt *sobek.Runtime, module *sobek.Object) {
+		exports, err := NewModuleExports(rt, opts)
+		if err != nil {
+			panic(rt.NewGoError(err))
+		}
+
+		if err := module.Set("exports", exports.Default); err != nil {
+			panic(rt.NewGoError(fmt.Errorf("setting module.exports: %w", err)))
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package url
+
+import "strings"
+
+// URLMutator changes the components of a URL within URL.Update. Its setters
+// follow the same rules as the URL ones: values they reject are ignored.
+type URLMutator struct {
+	record *urlRecord
+
+	// searchChanged reports whether SetSearch was called, so that Update
+	// only re-syncs searchParams when the query may have changed.
+	searchChanged bool
+}
+
+// SetProtocol is the batched counterpart of URL.SetProtocol.
+func (m *URLMutator) SetProtocol(protocol string) {
+	m.record.setProtocol(protocol)
+}
+
+// SetUsername is the batched counterpart of URL.SetUsername.
+func (m *URLMutator) SetUsername(username string) {
+	m.record.setUsername(username)
+}
+
+// SetPassword is the batched counterpart of URL.SetPassword.
+func (m *URLMutator) SetPassword(password string) {
+	m.record.setPassword(password)
+}
+
+// SetHost is the batched counterpart of URL.SetHost.
+func (m *URLMutator) SetHost(host string) {
+	m.record.setHost(host)
+}
+
+// SetHostname is the batched counterpart of URL.SetHostname.
+func (m *URLMutator) SetHostname(hostname string) {
+	m.record.setHostname(hostname)
+}
+
+// SetPort is the batched counterpart of URL.SetPort.
+func (m *URLMutator) SetPort(port string) {
+	m.record.setPort(port)
+}
+
+// SetPathname is the batched counterpart of URL.SetPathname.
+func (m *URLMutator) SetPathname(pathname string) {
+	m.record.setPathname(pathname)
+}
+
+// SetSearch is the batched counterpart of URL.SetSearch.
+func (m *URLMutator) SetSearch(search string) {
+	m.record.setSearch(search)
+	m.searchChanged = true
+}
+
+// SetHash is the batched counterpart of URL.SetHash.
+func (m *URLMutator) SetHash(hash string) {
+	m.record.setHash(hash)
+}
+
+// Update applies the changes fn makes through m to u as a single mutation:
+// searchParams is re-synced and the validator consulted once, after fn
+// returns, instead of after every setter. If fn or the validator returns an
+// error, u is left unchanged.
+func (u *URL) Update(fn func(m *URLMutator) error) error {
+	return u.mutate(ComponentUpdate, func(target *URL) error {
+		m := &URLMutator{record: target.inner}
+		if err := fn(m); err != nil {
+			return err
+		}
+
+		if m.searchChanged {
+			target.updateSearchParams(target.query())
+		}
+		return nil
+	})
+}
+
+// setProtocol implements the protocol setter on r.
+func (r *urlRecord) setProtocol(protocol string) {
+	_ = parseWithOverride(protocol+":", r, StateSchemeStart)
+}
+
+// setUsername implements the username setter on r.
+func (r *urlRecord) setUsername(username string) {
+	if r.cannotHaveUsernamePasswordPort() {
+		return
+	}
+	r.username = percentEncodeString(username, EncodeSetUserinfo)
+}
+
+// setPassword implements the password setter on r.
+func (r *urlRecord) setPassword(password string) {
+	if r.cannotHaveUsernamePasswordPort() {
+		return
+	}
+	r.password = percentEncodeString(password, EncodeSetUserinfo)
+}
+
+// setHost implements the host setter on r.
+func (r *urlRecord) setHost(host string) {
+	if r.hasOpaquePath() {
+		return
+	}
+	_ = parseWithOverride(host, r, StateHost)
+}
+
+// setHostname implements the hostname setter on r.
+func (r *urlRecord) setHostname(hostname string) {
+	if r.hasOpaquePath() {
+		return
+	}
+	_ = parseWithOverride(hostname, r, StateHostname)
+}
+
+// setPort implements the port setter on r.
+func (r *urlRecord) setPort(port string) {
+	if r.cannotHaveUsernamePasswordPort() {
+		return
+	}
+	if port == "" {
+		r.port = noPort
+		return
+	}
+	_ = parseWithOverride(port, r, StatePort)
+}
+
+// setPathname implements the pathname setter on r.
+func (r *urlRecord) setPathname(pathname string) {
+	if r.hasOpaquePath() {
+		return
+	}
+	r.path = nil
+	_ = parseWithOverride(pathname, r, StatePathStart)
+}
+
+// setSearch implements the search setter on r. The caller re-syncs
+// searchParams.
+func (r *urlRecord) setSearch(search string) {
+	if search == "" {
+		r.query = nil
+		return
+	}
+
+	r.query = strPtr("")
+	_ = parseWithOverride(strings.TrimPrefix(search, "?"), r, StateQuery)
+}
+
+// setHash implements the hash setter on r.
+func (r *urlRecord) setHash(hash string) {
+	if hash == "" {
+		r.fragment = nil
+		return
+	}
+
+	r.fragment = strPtr("")
+	_ = parseWithOverride(strings.TrimPrefix(hash, "#"), r, StateFragment)
+}
@@ -0,0 +1,49 @@
+package url
+
+import "golang.org/x/text/encoding"
+
+// ParseOptions tunes how NewURLWithOptions parses its input.
+type ParseOptions struct {
+	// Trace, when non-nil, receives every parser state transition and
+	// decision, to debug why an input parsed the way it did.
+	Trace Tracer
+
+	// OnDivergence, when non-nil, is called with a description of every
+	// result known to differ from the WHATWG URL Standard because of the
+	// other options: a scheme given rules by Schemes, or a query split on
+	// ";" or serialized differently because of SearchParams. It is called
+	// again for the URL's later href changes and query serializations, so
+	// the same message may be reported repeatedly.
+	OnDivergence func(message string)
+
+	// OnValidationError, when non-nil, is called with every validation
+	// error the parser recovers from, such as the backslash of
+	// "http:\\example.com", including later in values assigned to the
+	// URL's href. The input still parses.
+	OnValidationError func(ValidationError)
+
+	// Strict rejects inputs with validation errors, not only those the
+	// parser cannot recover from, so that "http:\\example.com" is not a
+	// valid URL. The base URL is not checked, but values assigned to the
+	// URL's href are.
+	Strict bool
+
+	// Validator, when non-nil, vets the constructed URL and every later
+	// component setter call on it.
+	Validator Validator
+
+	// Schemes, when non-nil, adds custom schemes to the standard ones. The
+	// URL keeps using the schemes registered at parse time for its setters.
+	Schemes *SchemeRegistry
+
+	// Encoding, when non-nil, is the encoding override of legacy documents,
+	// such as charmap.Windows1252: the query of special URLs other than ws:
+	// and wss: is percent-encoded in that charset, with code points it cannot
+	// represent written as HTML numeric character references. The href
+	// setter keeps using it, while the component setters always use UTF-8.
+	Encoding encoding.Encoding
+
+	// SearchParams tunes how the URL's searchParams parse its query, e.g.
+	// to also split pairs on ";". The query itself is not affected.
+	SearchParams SearchParamsOptions
+}
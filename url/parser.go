@@ -0,0 +1,828 @@
+package url
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+)
+
+// eof is the code point parser.at returns past the end of the input.
+const eof = -1
+
+// parser runs the WHATWG basic URL parser
+// (https://url.spec.whatwg.org/#concept-basic-url-parser).
+type parser struct {
+	input   []rune
+	pointer int
+	state   ParserState
+
+	// override is the state override used by the component setters, or ""
+	// when parsing a whole URL.
+	override ParserState
+
+	base *urlRecord
+	url  *urlRecord
+
+	buffer            strings.Builder
+	atSignSeen        bool
+	insideBrackets    bool
+	passwordTokenSeen bool
+
+	trace             Tracer
+	onValidationError func(ValidationError)
+
+	// encoding is the encoding override of the query, nil for UTF-8.
+	encoding encoding.Encoding
+}
+
+// parseURL runs the basic URL parser on input against an optional base and
+// returns the resulting URL record. Only the Trace and OnValidationError
+// hooks and the Schemes and Encoding options of opts are used.
+func parseURL(input string, base *urlRecord, opts ParseOptions) (*urlRecord, error) {
+	p := &parser{
+		base:              base,
+		url:               &urlRecord{port: noPort, schemes: opts.Schemes.snapshot()},
+		trace:             opts.Trace,
+		onValidationError: opts.OnValidationError,
+		encoding:          opts.Encoding,
+	}
+
+	trimmed := trimC0ControlOrSpace(input)
+	if trimmed != input {
+		p.validationError(ValidationInvalidURLUnit)
+	}
+	if strings.ContainsAny(trimmed, "\t\n\r") {
+		p.validationError(ValidationInvalidURLUnit)
+	}
+	p.input = []rune(removeTabAndNewline(trimmed))
+
+	if err := p.run(StateSchemeStart); err != nil {
+		return nil, err
+	}
+	return p.url, nil
+}
+
+// parseWithOverride runs the basic URL parser on input starting from the
+// override state, updating u in place as the component setters require.
+// Failures may leave u partially updated: the host setter keeps a valid
+// hostname even when the port that follows it is rejected.
+func parseWithOverride(input string, u *urlRecord, override ParserState) error {
+	p := &parser{
+		input:    []rune(removeTabAndNewline(input)),
+		url:      u,
+		override: override,
+	}
+	return p.run(override)
+}
+
+// trimC0ControlOrSpace removes leading and trailing C0 control or space
+// code points (U+0000 to U+0020), as the basic URL parser does first.
+func trimC0ControlOrSpace(input string) string {
+	return strings.TrimFunc(input, func(r rune) bool {
+		return r <= 0x20
+	})
+}
+
+// removeTabAndNewline removes every ASCII tab or newline from input.
+func removeTabAndNewline(input string) string {
+	if !strings.ContainsAny(input, "\t\n\r") {
+		return input
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, input)
+}
+
+// run drives the state machine until the input is consumed, a state
+// override returns early, or the input is rejected.
+func (p *parser) run(start ParserState) error {
+	p.state = start
+	for {
+		done, err := p.step(p.at(p.pointer))
+		if err != nil {
+			return err
+		}
+		if done || p.pointer >= len(p.input) {
+			return nil
+		}
+		p.pointer++
+	}
+}
+
+// step processes c, the code point at pointer, in the current state. It
+// reports true when a state override ends parsing early.
+//
+//nolint:cyclop // One case per parser state.
+func (p *parser) step(c rune) (bool, error) {
+	switch p.state {
+	case StateSchemeStart:
+		return p.schemeStartState(c)
+	case StateScheme:
+		return p.schemeState(c)
+	case StateNoScheme:
+		return p.noSchemeState(c)
+	case StateSpecialRelativeOrAuthority:
+		p.specialRelativeOrAuthorityState(c)
+	case StatePathOrAuthority:
+		p.pathOrAuthorityState(c)
+	case StateRelative:
+		p.relativeState(c)
+	case StateRelativeSlash:
+		p.relativeSlashState(c)
+	case StateSpecialAuthoritySlashes:
+		p.specialAuthoritySlashesState(c)
+	case StateSpecialAuthorityIgnoreSlashes:
+		p.specialAuthorityIgnoreSlashesState(c)
+	case StateAuthority:
+		return p.authorityState(c)
+	case StateHost, StateHostname:
+		return p.hostState(c)
+	case StatePort:
+		return p.portState(c)
+	case StateFile:
+		p.fileState(c)
+	case StateFileSlash:
+		p.fileSlashState(c)
+	case StateFileHost:
+		return p.fileHostState(c)
+	case StatePathStart:
+		p.pathStartState(c)
+	case StatePath:
+		p.pathState(c)
+	case StateOpaquePath:
+		p.opaquePathState(c)
+	case StateQuery:
+		p.queryState(c)
+	case StateFragment:
+		p.fragmentState(c)
+	}
+	return false, nil
+}
+
+// at returns the code point at i, or eof past the end of the input.
+func (p *parser) at(i int) rune {
+	if i < 0 || i >= len(p.input) {
+		return eof
+	}
+	return p.input[i]
+}
+
+// remainingStartsWith reports whether the code points after pointer start
+// with c.
+func (p *parser) remainingStartsWith(c rune) bool {
+	return p.at(p.pointer+1) == c
+}
+
+// startsWithWindowsDriveLetter reports whether the input starts with a
+// Windows drive letter at pointer.
+func (p *parser) startsWithWindowsDriveLetter() bool {
+	end := min(p.pointer+3, len(p.input))
+	if p.pointer >= end {
+		return false
+	}
+	return startsWithWindowsDriveLetter(string(p.input[p.pointer:end]))
+}
+
+// setState moves the parser to state, tracing the transition.
+func (p *parser) setState(state ParserState, message string) {
+	p.state = state
+	p.trace.emit(state, p.pointer, message)
+}
+
+// validationError reports a validation error found at pointer.
+func (p *parser) validationError(kind ValidationErrorType) {
+	if p.onValidationError != nil {
+		p.onValidationError(ValidationError{Type: kind, Pointer: p.pointer})
+	}
+}
+
+// checkURLUnit reports an invalid-URL-unit validation error when c is not a
+// URL code point or is a "%" not followed by two ASCII hex digits.
+func (p *parser) checkURLUnit(c rune) {
+	if p.onValidationError == nil {
+		return
+	}
+	if c == '%' {
+		if !isASCIIHexRune(p.at(p.pointer+1)) || !isASCIIHexRune(p.at(p.pointer+2)) {
+			p.validationError(ValidationInvalidURLUnit)
+		}
+		return
+	}
+	if !isURLCodePoint(c) {
+		p.validationError(ValidationInvalidURLUnit)
+	}
+}
+
+// fail traces why the input is rejected and returns the matching error.
+func (p *parser) fail(message string) error {
+	p.trace.emit(StateFailure, p.pointer, message)
+	return invalidURLError()
+}
+
+func (p *parser) schemeStartState(c rune) (bool, error) {
+	switch {
+	case isASCIIAlphaRune(c):
+		p.buffer.WriteRune(toASCIILower(c))
+		p.setState(StateScheme, "reading scheme")
+	case p.override == "":
+		p.setState(StateNoScheme, "input does not start with a scheme")
+		p.pointer--
+	default:
+		return false, p.fail("scheme does not start with an ASCII letter")
+	}
+	return false, nil
+}
+
+func (p *parser) schemeState(c rune) (bool, error) {
+	switch {
+	case isASCIIAlphaRune(c) || isASCIIDigitRune(c) || c == '+' || c == '-' || c == '.':
+		p.buffer.WriteRune(toASCIILower(c))
+	case c == ':':
+		return p.endScheme()
+	case p.override == "":
+		p.buffer.Reset()
+		p.setState(StateNoScheme, "no scheme found, starting over")
+		p.pointer = -1
+	default:
+		return false, p.fail("invalid scheme code point")
+	}
+	return false, nil
+}
+
+// endScheme handles the ":" ending the scheme.
+func (p *parser) endScheme() (bool, error) {
+	u := p.url
+	scheme := p.buffer.String()
+
+	if p.override != "" {
+		if u.isSpecial() != u.schemes.isSpecial(scheme) {
+			return true, nil
+		}
+		if (u.includesCredentials() || u.port != noPort) && scheme == "file" {
+			return true, nil
+		}
+		if u.scheme == "file" && u.host != nil && *u.host == "" {
+			return true, nil
+		}
+	}
+
+	u.scheme = scheme
+
+	if p.override != "" {
+		if u.port == u.defaultPort() {
+			u.port = noPort
+		}
+		return true, nil
+	}
+
+	p.buffer.Reset()
+	switch {
+	case scheme == "file":
+		if !p.remainingStartsWith('/') || p.at(p.pointer+2) != '/' {
+			p.validationError(ValidationSpecialSchemeMissingFollowingSolidus)
+		}
+		p.setState(StateFile, "found scheme file")
+	case u.isSpecial() && p.base != nil && p.base.scheme == scheme:
+		p.setState(StateSpecialRelativeOrAuthority, "found scheme "+scheme+", same as the base")
+	case u.isSpecial():
+		p.setState(StateSpecialAuthoritySlashes, "found special scheme "+scheme)
+	case p.remainingStartsWith('/'):
+		p.setState(StatePathOrAuthority, "found scheme "+scheme)
+		p.pointer++
+	default:
+		u.opaquePath = strPtr("")
+		p.setState(StateOpaquePath, "found scheme "+scheme+" without a slash, the path is opaque")
+	}
+	return false, nil
+}
+
+func (p *parser) noSchemeState(c rune) (bool, error) {
+	u, base := p.url, p.base
+	switch {
+	case base == nil:
+		return false, p.fail("no scheme and no base URL")
+	case base.hasOpaquePath() && c != '#':
+		return false, p.fail("no scheme and the base URL has an opaque path")
+	case base.hasOpaquePath():
+		u.scheme = base.scheme
+		u.opaquePath = base.opaquePath
+		u.query = base.query
+		u.fragment = strPtr("")
+		p.setState(StateFragment, "fragment against a base URL with an opaque path")
+	case base.scheme != "file":
+		p.setState(StateRelative, "no scheme, resolving against the base URL")
+		p.pointer--
+	default:
+		p.setState(StateFile, "no scheme, resolving against the file base URL")
+		p.pointer--
+	}
+	return false, nil
+}
+
+func (p *parser) specialRelativeOrAuthorityState(c rune) {
+	if c == '/' && p.remainingStartsWith('/') {
+		p.setState(StateSpecialAuthorityIgnoreSlashes, "found \"//\"")
+		p.pointer++
+		return
+	}
+	p.validationError(ValidationSpecialSchemeMissingFollowingSolidus)
+	p.setState(StateRelative, "no \"//\", resolving against the base URL")
+	p.pointer--
+}
+
+func (p *parser) pathOrAuthorityState(c rune) {
+	if c == '/' {
+		p.setState(StateAuthority, "found \"//\"")
+		return
+	}
+	p.setState(StatePath, "found a single slash")
+	p.pointer--
+}
+
+func (p *parser) relativeState(c rune) {
+	u, base := p.url, p.base
+	u.scheme = base.scheme
+
+	if c == '/' || (u.isSpecial() && c == '\\') {
+		if c == '\\' {
+			p.validationError(ValidationInvalidReverseSolidus)
+		}
+		p.setState(StateRelativeSlash, "relative input starts with a slash")
+		return
+	}
+
+	u.username = base.username
+	u.password = base.password
+	u.host = base.host
+	u.port = base.port
+	u.path = append([]string(nil), base.path...)
+	u.query = base.query
+
+	switch c {
+	case '?':
+		u.query = strPtr("")
+		p.setState(StateQuery, "keeping the base path, replacing the query")
+	case '#':
+		u.fragment = strPtr("")
+		p.setState(StateFragment, "keeping the base path and query, replacing the fragment")
+	case eof:
+	default:
+		u.query = nil
+		u.shortenPath()
+		p.setState(StatePath, "resolving the path against the base path")
+		p.pointer--
+	}
+}
+
+func (p *parser) relativeSlashState(c rune) {
+	u, base := p.url, p.base
+	switch {
+	case u.isSpecial() && (c == '/' || c == '\\'):
+		if c == '\\' {
+			p.validationError(ValidationInvalidReverseSolidus)
+		}
+		p.setState(StateSpecialAuthorityIgnoreSlashes, "scheme-relative input, inheriting base scheme "+u.scheme)
+	case c == '/':
+		p.setState(StateAuthority, "scheme-relative input, inheriting base scheme "+u.scheme)
+	default:
+		u.username = base.username
+		u.password = base.password
+		u.host = base.host
+		u.port = base.port
+		p.setState(StatePath, "path-absolute input, keeping the base host")
+		p.pointer--
+	}
+}
+
+func (p *parser) specialAuthoritySlashesState(c rune) {
+	if c == '/' && p.remainingStartsWith('/') {
+		p.setState(StateSpecialAuthorityIgnoreSlashes, "found \"//\"")
+		p.pointer++
+		return
+	}
+	p.validationError(ValidationSpecialSchemeMissingFollowingSolidus)
+	p.setState(StateSpecialAuthorityIgnoreSlashes, "missing \"//\" after a special scheme")
+	p.pointer--
+}
+
+func (p *parser) specialAuthorityIgnoreSlashesState(c rune) {
+	if c != '/' && c != '\\' {
+		p.setState(StateAuthority, "reading the authority")
+		p.pointer--
+		return
+	}
+	p.validationError(ValidationSpecialSchemeMissingFollowingSolidus)
+}
+
+func (p *parser) authorityState(c rune) (bool, error) {
+	u := p.url
+	switch {
+	case c == '@':
+		p.validationError(ValidationInvalidCredentials)
+		buffer := p.buffer.String()
+		if p.atSignSeen {
+			buffer = "%40" + buffer
+		}
+		p.atSignSeen = true
+
+		var username, password strings.Builder
+		username.WriteString(u.username)
+		password.WriteString(u.password)
+		for _, cp := range buffer {
+			if cp == ':' && !p.passwordTokenSeen {
+				p.passwordTokenSeen = true
+				continue
+			}
+			if p.passwordTokenSeen {
+				appendPercentEncoded(&password, cp, EncodeSetUserinfo)
+			} else {
+				appendPercentEncoded(&username, cp, EncodeSetUserinfo)
+			}
+		}
+		u.username = username.String()
+		u.password = password.String()
+		p.buffer.Reset()
+	case c == eof || c == '/' || c == '?' || c == '#' || (u.isSpecial() && c == '\\'):
+		if p.atSignSeen && p.buffer.Len() == 0 {
+			return false, p.fail("credentials are not followed by a host")
+		}
+		p.pointer -= utf8.RuneCountInString(p.buffer.String()) + 1
+		p.buffer.Reset()
+		p.setState(StateHost, "reading the host")
+	default:
+		p.buffer.WriteRune(c)
+	}
+	return false, nil
+}
+
+func (p *parser) hostState(c rune) (bool, error) {
+	u := p.url
+	switch {
+	case p.override != "" && u.scheme == "file":
+		p.pointer--
+		p.setState(StateFileHost, "setting the host of a file URL")
+	case c == ':' && !p.insideBrackets:
+		if p.buffer.Len() == 0 {
+			return false, p.fail("empty host before the port")
+		}
+		if p.override == StateHostname {
+			return false, p.fail("the hostname cannot include a port")
+		}
+		if err := p.setHost(); err != nil {
+			return false, err
+		}
+		p.setState(StatePort, "reading the port")
+	case c == eof || c == '/' || c == '?' || c == '#' || (u.isSpecial() && c == '\\'):
+		p.pointer--
+		if u.isSpecial() && p.buffer.Len() == 0 {
+			return false, p.fail("special scheme " + u.scheme + " requires a host")
+		}
+		if p.override != "" && p.buffer.Len() == 0 && (u.includesCredentials() || u.port != noPort) {
+			return true, nil
+		}
+		if err := p.setHost(); err != nil {
+			return false, err
+		}
+		if p.override != "" {
+			return true, nil
+		}
+		p.setState(StatePathStart, "parsed host")
+	default:
+		switch c {
+		case '[':
+			p.insideBrackets = true
+		case ']':
+			p.insideBrackets = false
+		}
+		p.buffer.WriteRune(c)
+	}
+	return false, nil
+}
+
+// setHost parses the buffer with the host parser and stores the result.
+func (p *parser) setHost() error {
+	host, err := parseHost(p.buffer.String(), !p.url.isSpecial(), p.validationError)
+	if err != nil {
+		return p.fail("invalid host " + strconv.Quote(p.buffer.String()))
+	}
+	p.url.host = strPtr(host.String())
+	p.buffer.Reset()
+	return nil
+}
+
+func (p *parser) portState(c rune) (bool, error) {
+	u := p.url
+	switch {
+	case isASCIIDigitRune(c):
+		p.buffer.WriteRune(c)
+	case c == eof || c == '/' || c == '?' || c == '#' || (u.isSpecial() && c == '\\') || p.override != "":
+		if p.buffer.Len() != 0 {
+			port, ok := parsePort(p.buffer.String())
+			if !ok {
+				return false, p.fail("port is out of range")
+			}
+			if port == u.defaultPort() {
+				port = noPort
+			}
+			u.port = port
+			p.buffer.Reset()
+			if p.override != "" {
+				return true, nil
+			}
+		}
+		if p.override != "" {
+			return false, p.fail("port does not start with a digit")
+		}
+		p.setState(StatePathStart, "parsed port")
+		p.pointer--
+	default:
+		return false, p.fail("invalid port code point")
+	}
+	return false, nil
+}
+
+// parsePort parses the ASCII digits of a port, reporting false when the
+// port does not fit in 16 bits.
+func parsePort(digits string) (int, bool) {
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return 0, true
+	}
+	if len(digits) > 5 {
+		return 0, false
+	}
+	port, err := strconv.Atoi(digits)
+	if err != nil || port > 65535 {
+		return 0, false
+	}
+	return port, true
+}
+
+func (p *parser) fileState(c rune) {
+	u, base := p.url, p.base
+	u.scheme = "file"
+	u.host = strPtr("")
+
+	switch {
+	case c == '/' || c == '\\':
+		if c == '\\' {
+			p.validationError(ValidationInvalidReverseSolidus)
+		}
+		p.setState(StateFileSlash, "found a slash after file:")
+	case base != nil && base.scheme == "file":
+		u.host = base.host
+		u.path = append([]string(nil), base.path...)
+		u.query = base.query
+		switch c {
+		case '?':
+			u.query = strPtr("")
+			p.setState(StateQuery, "keeping the base path, replacing the query")
+		case '#':
+			u.fragment = strPtr("")
+			p.setState(StateFragment, "keeping the base path and query, replacing the fragment")
+		case eof:
+		default:
+			u.query = nil
+			if p.startsWithWindowsDriveLetter() {
+				p.validationError(ValidationFileInvalidWindowsDriveLetter)
+				u.path = nil
+			} else {
+				u.shortenPath()
+			}
+			p.setState(StatePath, "resolving the path against the file base path")
+			p.pointer--
+		}
+	default:
+		p.setState(StatePath, "reading the path of a file URL")
+		p.pointer--
+	}
+}
+
+func (p *parser) fileSlashState(c rune) {
+	u, base := p.url, p.base
+	if c == '/' || c == '\\' {
+		if c == '\\' {
+			p.validationError(ValidationInvalidReverseSolidus)
+		}
+		p.setState(StateFileHost, "reading the host of a file URL")
+		return
+	}
+
+	if base != nil && base.scheme == "file" {
+		u.host = base.host
+		if !p.startsWithWindowsDriveLetter() && len(base.path) > 0 && isNormalizedWindowsDriveLetter(base.path[0]) {
+			p.trace.emit(StateFileSlash, p.pointer, "retaining the Windows drive letter of the base")
+			u.path = append(u.path, base.path[0])
+		}
+	}
+	p.setState(StatePath, "reading the path of a file URL")
+	p.pointer--
+}
+
+func (p *parser) fileHostState(c rune) (bool, error) {
+	u := p.url
+	switch c {
+	case eof, '/', '\\', '?', '#':
+		p.pointer--
+		buffer := p.buffer.String()
+		switch {
+		case p.override == "" && isWindowsDriveLetter(buffer):
+			// The buffer is kept: it becomes the first path segment.
+			p.validationError(ValidationFileInvalidWindowsDriveLetterHost)
+			p.setState(StatePath, "file host is a Windows drive letter")
+		case buffer == "":
+			u.host = strPtr("")
+			if p.override != "" {
+				return true, nil
+			}
+			p.setState(StatePathStart, "file URL with an empty host")
+		default:
+			host, err := parseHost(buffer, !u.isSpecial(), p.validationError)
+			if err != nil {
+				return false, p.fail("invalid host " + strconv.Quote(buffer))
+			}
+			serialized := host.String()
+			if serialized == "localhost" {
+				serialized = ""
+			}
+			u.host = &serialized
+			if p.override != "" {
+				return true, nil
+			}
+			p.buffer.Reset()
+			p.setState(StatePathStart, "parsed file host")
+		}
+	default:
+		p.buffer.WriteRune(c)
+	}
+	return false, nil
+}
+
+func (p *parser) pathStartState(c rune) {
+	u := p.url
+	switch {
+	case u.isSpecial():
+		if c == '\\' {
+			p.validationError(ValidationInvalidReverseSolidus)
+		}
+		p.setState(StatePath, "reading the path")
+		if c != '/' && c != '\\' {
+			p.pointer--
+		}
+	case p.override == "" && c == '?':
+		u.query = strPtr("")
+		p.setState(StateQuery, "reading the query")
+	case p.override == "" && c == '#':
+		u.fragment = strPtr("")
+		p.setState(StateFragment, "reading the fragment")
+	case c != eof:
+		p.setState(StatePath, "reading the path")
+		if c != '/' {
+			p.pointer--
+		}
+	case p.override != "" && u.host == nil:
+		u.path = append(u.path, "")
+	}
+}
+
+func (p *parser) pathState(c rune) {
+	u := p.url
+	slash := c == '/' || (u.isSpecial() && c == '\\')
+
+	if c != eof && !slash && (p.override != "" || (c != '?' && c != '#')) {
+		p.checkURLUnit(c)
+		appendPercentEncoded(&p.buffer, c, EncodeSetPath)
+		return
+	}
+
+	if c == '\\' {
+		p.validationError(ValidationInvalidReverseSolidus)
+	}
+
+	buffer := p.buffer.String()
+	switch {
+	case isDoubleDotSegment(buffer):
+		u.shortenPath()
+		if !slash {
+			u.path = append(u.path, "")
+		}
+	case isSingleDotSegment(buffer):
+		if !slash {
+			u.path = append(u.path, "")
+		}
+	default:
+		if u.scheme == "file" && len(u.path) == 0 && isWindowsDriveLetter(buffer) {
+			buffer = buffer[:1] + ":"
+		}
+		u.path = append(u.path, buffer)
+	}
+	p.buffer.Reset()
+
+	switch c {
+	case '?':
+		u.query = strPtr("")
+		p.setState(StateQuery, "reading the query")
+	case '#':
+		u.fragment = strPtr("")
+		p.setState(StateFragment, "reading the fragment")
+	}
+}
+
+// opaquePathState consumes the whole opaque path at once, rather than one
+// code point per step, so that long data: URLs are not copied repeatedly.
+func (p *parser) opaquePathState(c rune) {
+	u := p.url
+
+	var b strings.Builder
+	b.WriteString(*u.opaquePath)
+	for c != eof && c != '?' && c != '#' {
+		p.checkURLUnit(c)
+		next := p.at(p.pointer + 1)
+		if c == ' ' && (next == '?' || next == '#') {
+			b.WriteString("%20")
+		} else {
+			appendPercentEncoded(&b, c, EncodeSetC0Control)
+		}
+		p.pointer++
+		c = next
+	}
+	u.opaquePath = strPtr(b.String())
+
+	switch c {
+	case '?':
+		u.query = strPtr("")
+		p.setState(StateQuery, "reading the query")
+	case '#':
+		u.fragment = strPtr("")
+		p.setState(StateFragment, "reading the fragment")
+	}
+}
+
+func (p *parser) queryState(c rune) {
+	u := p.url
+	if (p.override == "" && c == '#') || c == eof {
+		set := EncodeSetQuery
+		if u.isSpecial() {
+			set = EncodeSetSpecialQuery
+		}
+
+		// The encoding override only applies to special, non-WebSocket URLs.
+		encoded := percentEncodeString(p.buffer.String(), set)
+		if !isUTF8(p.encoding) && u.isSpecial() && u.scheme != "ws" && u.scheme != "wss" {
+			encoded = percentEncodeAfterEncoding(p.encoding, p.buffer.String(), set, false)
+		}
+		u.query = strPtr(*u.query + encoded)
+		p.buffer.Reset()
+
+		if c == '#' {
+			u.fragment = strPtr("")
+			p.setState(StateFragment, "reading the fragment")
+		}
+		return
+	}
+	p.checkURLUnit(c)
+	p.buffer.WriteRune(c)
+}
+
+// fragmentState consumes the whole fragment at once.
+func (p *parser) fragmentState(c rune) {
+	u := p.url
+
+	var b strings.Builder
+	b.WriteString(*u.fragment)
+	for c != eof {
+		p.checkURLUnit(c)
+		appendPercentEncoded(&b, c, EncodeSetFragment)
+		p.pointer++
+		c = p.at(p.pointer)
+	}
+	u.fragment = strPtr(b.String())
+}
+
+// isASCIIAlphaRune reports whether c is an ASCII alpha.
+func isASCIIAlphaRune(c rune) bool {
+	return c >= 0 && c < utf8.RuneSelf && isASCIIAlpha(byte(c))
+}
+
+// isASCIIHexRune reports whether c is an ASCII hex digit.
+func isASCIIHexRune(c rune) bool {
+	return c >= 0 && c < utf8.RuneSelf && unhex(byte(c)) >= 0
+}
+
+// isASCIIDigitRune reports whether c is an ASCII digit.
+func isASCIIDigitRune(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+// toASCIILower lowercases c if it is an ASCII upper alpha.
+func toASCIILower(c rune) rune {
+	if c >= 'A' && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
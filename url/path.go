@@ -0,0 +1,50 @@
+package url
+
+import "strings"
+
+// isWindowsDriveLetter reports whether s is a Windows drive letter: an ASCII
+// alpha followed by ":" or "|" (https://url.spec.whatwg.org/#windows-drive-letter).
+func isWindowsDriveLetter(s string) bool {
+	return len(s) == 2 && isASCIIAlpha(s[0]) && (s[1] == ':' || s[1] == '|')
+}
+
+// isNormalizedWindowsDriveLetter reports whether s is a Windows drive letter
+// whose second code point is ":".
+func isNormalizedWindowsDriveLetter(s string) bool {
+	return isWindowsDriveLetter(s) && s[1] == ':'
+}
+
+// startsWithWindowsDriveLetter implements
+// https://url.spec.whatwg.org/#start-with-a-windows-drive-letter.
+func startsWithWindowsDriveLetter(s string) bool {
+	if len(s) < 2 || !isWindowsDriveLetter(s[:2]) {
+		return false
+	}
+	if len(s) == 2 {
+		return true
+	}
+	switch s[2] {
+	case '/', '\\', '?', '#':
+		return true
+	}
+	return false
+}
+
+// isSingleDotSegment reports whether segment is "." or "%2e", ignoring case
+// (https://url.spec.whatwg.org/#single-dot-path-segment).
+func isSingleDotSegment(segment string) bool {
+	return segment == "." || strings.EqualFold(segment, "%2e")
+}
+
+// isDoubleDotSegment reports whether segment is "..", ".%2e", "%2e." or
+// "%2e%2e", ignoring case (https://url.spec.whatwg.org/#double-dot-path-segment).
+func isDoubleDotSegment(segment string) bool {
+	if len(segment) > len("%2e%2e") {
+		return false
+	}
+	switch strings.ToLower(segment) {
+	case "..", ".%2e", "%2e.", "%2e%2e":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package url
+
+import (
+	"strconv"
+	"strings"
+)
+
+// noPort is the urlRecord.port value of URLs without a port.
+const noPort = -1
+
+// urlRecord is a WHATWG URL record (https://url.spec.whatwg.org/#concept-url).
+//
+// Every component is stored in its serialized, percent-encoded form. The
+// string pointers are never mutated in place, so records can be copied
+// shallowly as long as path is copied too.
+type urlRecord struct {
+	scheme   string
+	username string
+	password string
+
+	// host is nil when the URL has no host, which differs from an empty host.
+	host *string
+
+	// port is noPort when the URL has no port.
+	port int
+
+	// path holds the path segments of URLs without an opaque path.
+	path []string
+
+	// opaquePath is non-nil for URLs with an opaque path, such as
+	// "mailto:someone@example.com".
+	opaquePath *string
+
+	query    *string
+	fragment *string
+
+	// schemes holds the additional schemes registered when the record was
+	// parsed. It is nil when only the standard schemes are known.
+	schemes *SchemeRegistry
+}
+
+// strPtr returns a pointer to a copy of s.
+func strPtr(s string) *string {
+	return &s
+}
+
+// clone returns a copy of r that can be mutated independently.
+func (r *urlRecord) clone() *urlRecord {
+	c := *r
+	c.path = append([]string(nil), r.path...)
+	return &c
+}
+
+// isSpecial reports whether r has a special scheme.
+func (r *urlRecord) isSpecial() bool {
+	return r.schemes.isSpecial(r.scheme)
+}
+
+// defaultPort returns the default port of r's scheme, or noPort.
+func (r *urlRecord) defaultPort() int {
+	return r.schemes.defaultPort(r.scheme)
+}
+
+// hasOpaquePath reports whether r has an opaque path.
+func (r *urlRecord) hasOpaquePath() bool {
+	return r.opaquePath != nil
+}
+
+// includesCredentials reports whether r has a username or a password.
+func (r *urlRecord) includesCredentials() bool {
+	return r.username != "" || r.password != ""
+}
+
+// cannotHaveUsernamePasswordPort implements
+// https://url.spec.whatwg.org/#cannot-have-a-username-password-port.
+func (r *urlRecord) cannotHaveUsernamePasswordPort() bool {
+	return r.host == nil || *r.host == "" || r.scheme == "file"
+}
+
+// shortenPath implements https://url.spec.whatwg.org/#shorten-a-urls-path.
+func (r *urlRecord) shortenPath() {
+	if r.scheme == "file" && len(r.path) == 1 && isNormalizedWindowsDriveLetter(r.path[0]) {
+		return
+	}
+	if len(r.path) > 0 {
+		r.path = r.path[:len(r.path)-1]
+	}
+}
+
+// serializePath implements https://url.spec.whatwg.org/#url-path-serializer.
+func (r *urlRecord) serializePath() string {
+	if r.opaquePath != nil {
+		return *r.opaquePath
+	}
+
+	var b strings.Builder
+	for _, segment := range r.path {
+		b.WriteByte('/')
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+// serialize implements https://url.spec.whatwg.org/#concept-url-serializer.
+func (r *urlRecord) serialize(excludeFragment bool) string {
+	var b strings.Builder
+	b.WriteString(r.scheme)
+	b.WriteByte(':')
+
+	if r.host != nil {
+		b.WriteString("//")
+		if r.includesCredentials() {
+			b.WriteString(r.username)
+			if r.password != "" {
+				b.WriteByte(':')
+				b.WriteString(r.password)
+			}
+			b.WriteByte('@')
+		}
+		b.WriteString(*r.host)
+		if r.port != noPort {
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(r.port))
+		}
+	}
+
+	// Without the "/." prefix, a path starting with an empty segment would
+	// be reparsed as an authority.
+	if r.host == nil && r.opaquePath == nil && len(r.path) > 1 && r.path[0] == "" {
+		b.WriteString("/.")
+	}
+	b.WriteString(r.serializePath())
+
+	if r.query != nil {
+		b.WriteByte('?')
+		b.WriteString(*r.query)
+	}
+	if !excludeFragment && r.fragment != nil {
+		b.WriteByte('#')
+		b.WriteString(*r.fragment)
+	}
+
+	return b.String()
+}
+
+// origin returns the serialization of the origin of r
+// (https://url.spec.whatwg.org/#concept-url-origin). Opaque origins
+// serialize to "null".
+func (r *urlRecord) origin() string {
+	switch r.scheme {
+	case "blob":
+		// The origin of "blob:https://example.com/uuid" is the one of the
+		// URL in its path, provided that URL is an http(s) URL.
+		pathURL, err := parseURL(r.serializePath(), nil, ParseOptions{})
+		if err == nil && (pathURL.scheme == "http" || pathURL.scheme == "https") {
+			return pathURL.origin()
+		}
+	case "file":
+	default:
+		// Besides http, https, ws, wss and ftp, this covers the special
+		// schemes of a SchemeRegistry.
+		if r.isSpecial() {
+			origin := r.scheme + "://" + *r.host
+			if r.port != noPort {
+				origin += ":" + strconv.Itoa(r.port)
+			}
+			return origin
+		}
+	}
+	return "null"
+}
+
+// isSpecialScheme reports whether scheme is one of the WHATWG special
+// schemes, which get dedicated parsing rules.
+func isSpecialScheme(scheme string) bool {
+	switch scheme {
+	case "http", "https", "ws", "wss", "ftp", "file":
+		return true
+	}
+	return false
+}
+
+// defaultPort returns the default port of scheme, or noPort when the scheme
+// has none (https://url.spec.whatwg.org/#default-port).
+func defaultPort(scheme string) int {
+	switch scheme {
+	case "http", "ws":
+		return 80
+	case "https", "wss":
+		return 443
+	case "ftp":
+		return 21
+	}
+	return noPort
+}
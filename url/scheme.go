@@ -0,0 +1,103 @@
+package url
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Scheme describes how URLs with a scheme registered in a SchemeRegistry are
+// parsed and serialized.
+type Scheme struct {
+	// Special makes the scheme follow the rules of the special schemes such
+	// as http: a host is required and IDNA-processed, "\" separates path
+	// segments, and the origin is derived from the scheme, host and port.
+	Special bool
+
+	// DefaultPort is elided from the URL when it is the URL's port. Zero
+	// means the scheme has no default port.
+	DefaultPort uint16
+}
+
+// SchemeRegistry holds additional schemes on top of the special schemes of
+// the WHATWG URL Standard, such as "grafana". It is safe for concurrent
+// use, but registering a scheme does not affect URLs parsed before: they
+// keep the schemes registered when they were parsed.
+//
+// A nil *SchemeRegistry only knows the standard schemes.
+type SchemeRegistry struct {
+	mu sync.RWMutex
+
+	// schemes is replaced rather than mutated by Register, so that
+	// snapshots can share it.
+	schemes map[string]Scheme
+}
+
+// NewSchemeRegistry returns an empty SchemeRegistry.
+func NewSchemeRegistry() *SchemeRegistry {
+	return &SchemeRegistry{schemes: make(map[string]Scheme)}
+}
+
+// Register adds name, a lowercase scheme without the trailing ":", to r. The
+// standard special schemes cannot be redefined.
+func (r *SchemeRegistry) Register(name string, scheme Scheme) error {
+	if !isValidScheme(name) || name != strings.ToLower(name) {
+		return NewError(TypeError, fmt.Sprintf("invalid scheme %q", name))
+	}
+	if isSpecialScheme(name) {
+		return NewError(TypeError, fmt.Sprintf("cannot redefine the special scheme %q", name))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schemes := make(map[string]Scheme, len(r.schemes)+1)
+	for registered, s := range r.schemes {
+		schemes[registered] = s
+	}
+	schemes[name] = scheme
+	r.schemes = schemes
+	return nil
+}
+
+// snapshot returns a registry holding the schemes currently registered in
+// r, which later registrations leave unchanged.
+func (r *SchemeRegistry) snapshot() *SchemeRegistry {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return &SchemeRegistry{schemes: r.schemes}
+}
+
+// lookup returns the registered scheme named name.
+func (r *SchemeRegistry) lookup(name string) (Scheme, bool) {
+	if r == nil {
+		return Scheme{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	scheme, ok := r.schemes[name]
+	return scheme, ok
+}
+
+// isSpecial reports whether scheme is special, either as a standard special
+// scheme or as a registered one.
+func (r *SchemeRegistry) isSpecial(scheme string) bool {
+	if isSpecialScheme(scheme) {
+		return true
+	}
+	registered, ok := r.lookup(scheme)
+	return ok && registered.Special
+}
+
+// defaultPort returns the default port of scheme, or noPort when it has none.
+func (r *SchemeRegistry) defaultPort(scheme string) int {
+	if port := defaultPort(scheme); port != noPort {
+		return port
+	}
+	if registered, ok := r.lookup(scheme); ok && registered.DefaultPort != 0 {
+		return int(registered.DefaultPort)
+	}
+	return noPort
+}
@@ -3,12 +3,18 @@ package url
 import (
 	"sort"
 	"strings"
+
+	"golang.org/x/text/encoding"
 )
 
 // urlParam represents a single key-value pair in URLSearchParams.
 type urlParam struct {
 	key   string
 	value string
+
+	// valueless reports that the pair was parsed from a name without "=",
+	// as in "?flag". Pairs added or changed by the API always have one.
+	valueless bool
 }
 
 // URLSearchParams represents a collection of URL query parameters.
@@ -23,7 +29,57 @@ type URLSearchParams struct {
 	// owner is the URL that owns this URLSearchParams, if any.
 	// When set, mutations to the params will update the owner's query string.
 	owner *URL
-}
+
+	// opts tunes how query strings are parsed into the params.
+	opts SearchParamsOptions
+
+	// onDivergence, when non-nil, is called when opts make the params parse
+	// or serialize differently from the WHATWG URL Standard.
+	onDivergence func(message string)
+}
+
+// SearchParamsOptions tunes how URLSearchParams parse query strings, for
+// services that deviate from application/x-www-form-urlencoded.
+type SearchParamsOptions struct {
+	// SemicolonSeparators also splits pairs on ";", for legacy services
+	// emitting "a=1;b=2", which would otherwise parse as a single "a" name
+	// with the "1;b=2" value. Serialization keeps using "&".
+	SemicolonSeparators bool
+
+	// Serialization selects how names and values are percent-encoded when
+	// the params are serialized, including into the owning URL's query.
+	// StringWithEncoding always uses the form-urlencoded serializer.
+	Serialization SerializationProfile
+
+	// SpaceAsPercent20 writes spaces as "%20" instead of "+", leaving the
+	// rest of the serialization profile unchanged, for backends that
+	// verify signatures over queries encoded that way.
+	SpaceAsPercent20 bool
+
+	// PreserveValueless serializes the pairs parsed from a name without "=",
+	// such as "flag" in "?flag&a=1", the way they were written instead of as
+	// "flag=", for backends telling the two apart. Setting such a pair's
+	// value, even to "", gives it an "=" again.
+	PreserveValueless bool
+}
+
+// SerializationProfile selects how URLSearchParams serialize names and
+// values.
+type SerializationProfile int
+
+const (
+	// SerializeFormURLEncoded is the application/x-www-form-urlencoded
+	// serializer of the URL Standard: spaces become "+", and every byte but
+	// ASCII alphanumerics, "*", "-", "." and "_" is percent-encoded.
+	SerializeFormURLEncoded SerializationProfile = iota
+	// SerializeRFC3986 follows the query grammar of RFC 3986, for APIs that
+	// do not read "+" as a space: spaces become "%20", and the unreserved
+	// characters, the sub-delimiters, ":", "@", "/" and "?" are kept, except
+	// "&", "=", "+" and ";", which are percent-encoded as servers would
+	// otherwise read them as separators or spaces, and "'", which special
+	// URLs percent-encode in their query.
+	SerializeRFC3986
+)
 
 // NewURLSearchParams creates an empty URLSearchParams.
 func NewURLSearchParams() *URLSearchParams {
@@ -36,8 +92,22 @@ func NewURLSearchParams() *URLSearchParams {
 //
 // The input may or may not have a leading "?".
 func NewURLSearchParamsFromString(raw string) *URLSearchParams {
+	return NewURLSearchParamsFromStringWithOptions(raw, SearchParamsOptions{})
+}
+
+// NewURLSearchParamsFromStringWithOptions is like NewURLSearchParamsFromString
+// but parses raw according to opts, which the params keep for later parses.
+func NewURLSearchParamsFromStringWithOptions(raw string, opts SearchParamsOptions) *URLSearchParams {
+	return newURLSearchParamsFromString(raw, opts, nil)
+}
+
+// newURLSearchParamsFromString is like NewURLSearchParamsFromStringWithOptions,
+// reporting divergences from the WHATWG URL Standard to onDivergence.
+func newURLSearchParamsFromString(raw string, opts SearchParamsOptions, onDivergence func(string)) *URLSearchParams {
 	sp := &URLSearchParams{
-		entries: make([]urlParam, 0),
+		entries:      make([]urlParam, 0),
+		opts:         opts,
+		onDivergence: onDivergence,
 	}
 
 	// Strip leading ? if present
@@ -47,7 +117,7 @@ func NewURLSearchParamsFromString(raw string) *URLSearchParams {
 		return sp
 	}
 
-	sp.entries = parseFormEncoded(raw)
+	sp.entries = sp.parseQuery(raw)
 	return sp
 }
 
@@ -89,6 +159,7 @@ func NewURLSearchParamsFromMap(m map[string]string) *URLSearchParams {
 func (sp *URLSearchParams) Clone() *URLSearchParams {
 	clone := &URLSearchParams{
 		entries: make([]urlParam, len(sp.entries)),
+		opts:    sp.opts,
 	}
 	copy(clone.entries, sp.entries)
 	return clone
@@ -107,6 +178,90 @@ func (sp *URLSearchParams) Append(key, value string) {
 	sp.syncOwner()
 }
 
+// AppendAll adds the given key-value pairs to the end of the list, in
+// order. Unlike calling Append for each pair, it updates the owner's query
+// string once.
+func (sp *URLSearchParams) AppendAll(pairs [][2]string) {
+	if len(pairs) == 0 {
+		return
+	}
+
+	for _, pair := range pairs {
+		sp.entries = append(sp.entries, urlParam{key: pair[0], value: pair[1]})
+	}
+	sp.syncOwner()
+}
+
+// MergeStrategy selects how Merge combines the params of two lists.
+type MergeStrategy int
+
+const (
+	// MergeAppend appends every pair of the other list, as AppendAll does.
+	MergeAppend MergeStrategy = iota
+	// MergeKeepFirst only appends the pairs whose name is not in the list yet.
+	MergeKeepFirst
+	// MergeOverwrite replaces the values of the names both lists hold with
+	// the ones of the other list, at the position of their first occurrence
+	// as Set does, and appends the pairs of the other names.
+	MergeOverwrite
+)
+
+// Merge combines the pairs of other into sp according to strategy, updating
+// the owner's query string once.
+func (sp *URLSearchParams) Merge(other *URLSearchParams, strategy MergeStrategy) {
+	if len(other.entries) == 0 {
+		return
+	}
+
+	sp.merge(other, strategy)
+	sp.syncOwner()
+}
+
+// merge combines the pairs of other into sp as Merge does, leaving the
+// owner's query string for the caller to update.
+func (sp *URLSearchParams) merge(other *URLSearchParams, strategy MergeStrategy) {
+	// Snapshot other first, as it may be sp itself.
+	incoming := make([]urlParam, len(other.entries))
+	copy(incoming, other.entries)
+
+	present := make(map[string]bool, len(sp.entries))
+	for _, entry := range sp.entries {
+		present[entry.key] = true
+	}
+
+	switch strategy {
+	case MergeKeepFirst:
+		for _, entry := range incoming {
+			if !present[entry.key] {
+				sp.entries = append(sp.entries, entry)
+			}
+		}
+	case MergeOverwrite:
+		_, replacements := other.groupByKey()
+		merged := make([]urlParam, 0, len(sp.entries)+len(incoming))
+		for _, entry := range sp.entries {
+			values, ok := replacements[entry.key]
+			if !ok {
+				merged = append(merged, entry)
+				continue
+			}
+			for _, value := range values {
+				merged = append(merged, urlParam{key: entry.key, value: value})
+			}
+			// Later occurrences of the name are dropped.
+			replacements[entry.key] = nil
+		}
+		for _, entry := range incoming {
+			if !present[entry.key] {
+				merged = append(merged, entry)
+			}
+		}
+		sp.entries = merged
+	default:
+		sp.entries = append(sp.entries, incoming...)
+	}
+}
+
 // Delete removes entries with the given key. It accepts an optional value to
 // match the behavior of the JS bindings: when value is nil all entries with
 // the key are removed, otherwise only exact key/value pairs are removed.
@@ -229,6 +384,65 @@ func (sp *URLSearchParams) Sort() {
 	sp.syncOwner()
 }
 
+// Swap exchanges the entries at positions i and j. It returns a RangeError
+// when either index is outside [0, Size()).
+func (sp *URLSearchParams) Swap(i, j int) error {
+	if i < 0 || i >= len(sp.entries) || j < 0 || j >= len(sp.entries) {
+		return NewError(RangeError, "Index out of range")
+	}
+	sp.entries[i], sp.entries[j] = sp.entries[j], sp.entries[i]
+	sp.syncOwner()
+	return nil
+}
+
+// MoveToFront moves every entry with the given key before all other entries,
+// keeping the relative order within both groups.
+func (sp *URLSearchParams) MoveToFront(key string) {
+	sp.partitionByKey(key, true)
+}
+
+// MoveToEnd moves every entry with the given key after all other entries,
+// keeping the relative order within both groups.
+func (sp *URLSearchParams) MoveToEnd(key string) {
+	sp.partitionByKey(key, false)
+}
+
+// partitionByKey stably splits entries into those matching key and the rest,
+// placing the matching group first when front is true.
+func (sp *URLSearchParams) partitionByKey(key string, front bool) {
+	matching := make([]urlParam, 0, len(sp.entries))
+	others := make([]urlParam, 0, len(sp.entries))
+	for _, entry := range sp.entries {
+		if entry.key == key {
+			matching = append(matching, entry)
+		} else {
+			others = append(others, entry)
+		}
+	}
+
+	if len(matching) == 0 {
+		return
+	}
+
+	first, second := others, matching
+	if front {
+		first, second = matching, others
+	}
+
+	reordered := make([]urlParam, 0, len(sp.entries))
+	reordered = append(reordered, first...)
+	sp.entries = append(reordered, second...)
+	sp.syncOwner()
+}
+
+// ToSorted returns a sorted copy of the params, as Sort would produce,
+// without mutating sp or its owning URL. The copy has no owner.
+func (sp *URLSearchParams) ToSorted() *URLSearchParams {
+	sorted := sp.Clone()
+	sorted.Sort()
+	return sorted
+}
+
 // compareByCodeUnits compares two strings by their UTF-16 code units.
 // This matches JavaScript's default string comparison behavior.
 func compareByCodeUnits(a, b string) int {
@@ -302,16 +516,81 @@ func (sp *URLSearchParams) Size() int {
 
 // String returns the serialized query string (without leading "?").
 func (sp *URLSearchParams) String() string {
-	return encodeFormEncoded(sp.entries)
+	serialized := sp.opts.serialize(sp.entries)
+	sp.reportSerializationDivergence(serialized)
+	return serialized
 }
 
-// ForEach calls the callback function for each entry.
+// StringWithEncoding is like String but serializes names and values in enc,
+// as forms of legacy non-UTF-8 pages are submitted: with windows-1252, "é"
+// becomes "%E9" and "☃", which it cannot represent, "%26%239731%3B". A nil
+// enc means UTF-8.
+func (sp *URLSearchParams) StringWithEncoding(enc encoding.Encoding) string {
+	if isUTF8(enc) {
+		return sp.String()
+	}
+
+	parts := make([]string, len(sp.entries))
+	for i, entry := range sp.entries {
+		parts[i] = percentEncodeAfterEncoding(enc, entry.key, EncodeSetFormURLEncoded, true) + "=" +
+			percentEncodeAfterEncoding(enc, entry.value, EncodeSetFormURLEncoded, true)
+	}
+	return strings.Join(parts, "&")
+}
+
+// ForEach calls the callback function for each entry. Like the WHATWG
+// forEach, it reads the list by index on every step, so entries the callback
+// appends are visited and deleted ones skipped.
 func (sp *URLSearchParams) ForEach(callback func(value, key string)) {
-	for _, entry := range sp.entries {
+	for i := 0; i < len(sp.entries); i++ {
+		entry := sp.entries[i]
 		callback(entry.value, entry.key)
 	}
 }
 
+// IsValueless reports whether the first pair with the given name was parsed
+// from the name alone, without "=", as "flag" in "?flag&a=1". It is false
+// when there is no such pair.
+func (sp *URLSearchParams) IsValueless(name string) bool {
+	for _, entry := range sp.entries {
+		if entry.key == name {
+			return entry.valueless
+		}
+	}
+	return false
+}
+
+// ToMap returns the params grouped by name: a name appearing once maps to
+// its value as a string, and a repeated name to all its values, in order,
+// as a []string.
+func (sp *URLSearchParams) ToMap() map[string]any {
+	keys, values := sp.groupByKey()
+
+	result := make(map[string]any, len(keys))
+	for _, key := range keys {
+		if group := values[key]; len(group) == 1 {
+			result[key] = group[0]
+		} else {
+			result[key] = group
+		}
+	}
+	return result
+}
+
+// groupByKey returns the distinct names of the params in order of first
+// appearance, along with the values of each name.
+func (sp *URLSearchParams) groupByKey() ([]string, map[string][]string) {
+	var keys []string
+	values := make(map[string][]string)
+	for _, entry := range sp.entries {
+		if _, ok := values[entry.key]; !ok {
+			keys = append(keys, entry.key)
+		}
+		values[entry.key] = append(values[entry.key], entry.value)
+	}
+	return keys, values
+}
+
 // Entries returns an iterator-like slice of [key, value] pairs.
 func (sp *URLSearchParams) Entries() [][2]string {
 	result := make([][2]string, len(sp.entries))
@@ -378,6 +657,39 @@ func unhex(c byte) int {
 	return -1
 }
 
+// parse parses the query string s according to o.
+func (o SearchParamsOptions) parse(s string) []urlParam {
+	if o.SemicolonSeparators {
+		s = strings.ReplaceAll(s, ";", "&")
+	}
+	return parseFormEncoded(s)
+}
+
+// serialize serializes entries according to o.
+func (o SearchParamsOptions) serialize(entries []urlParam) string {
+	encode := formEncode
+	if o.Serialization == SerializeRFC3986 {
+		encode = rfc3986Encode
+	}
+
+	parts := make([]string, len(entries))
+	for i, entry := range entries {
+		if o.PreserveValueless && entry.valueless {
+			parts[i] = encode(entry.key)
+			continue
+		}
+		parts[i] = encode(entry.key) + "=" + encode(entry.value)
+	}
+	serialized := strings.Join(parts, "&")
+
+	if o.SpaceAsPercent20 && o.Serialization != SerializeRFC3986 {
+		// A literal "+" is serialized as "%2B", so every "+" left is a
+		// space.
+		serialized = strings.ReplaceAll(serialized, "+", "%20")
+	}
+	return serialized
+}
+
 // parseFormEncoded parses an application/x-www-form-urlencoded string per
 // https://url.spec.whatwg.org/#concept-urlencoded-parser.
 func parseFormEncoded(s string) []urlParam {
@@ -394,12 +706,14 @@ func parseFormEncoded(s string) []urlParam {
 		}
 
 		var key, value string
+		valueless := false
 		if idx := strings.Index(pair, "="); idx >= 0 {
 			key = pair[:idx]
 			value = pair[idx+1:]
 		} else {
 			key = pair
 			value = ""
+			valueless = true
 		}
 
 		// Decode + as space, then percent-decode
@@ -411,8 +725,9 @@ func parseFormEncoded(s string) []urlParam {
 		decodedValue := percentDecode(value)
 
 		entries = append(entries, urlParam{
-			key:   decodedKey,
-			value: decodedValue,
+			key:       decodedKey,
+			value:     decodedValue,
+			valueless: valueless,
 		})
 	}
 
@@ -472,6 +787,27 @@ func formEncode(s string) string {
 	return builder.String()
 }
 
+// rfc3986Encode percent-encodes s for the SerializeRFC3986 profile.
+func rfc3986Encode(s string) string {
+	var builder strings.Builder
+	builder.Grow(len(s) * 3) // worst case: all characters need encoding
+
+	for _, c := range []byte(s) {
+		switch {
+		case c >= '0' && c <= '9', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+			builder.WriteByte(c)
+		case strings.IndexByte("-._~!$()*,:@/?", c) >= 0:
+			builder.WriteByte(c)
+		default:
+			builder.WriteByte('%')
+			builder.WriteByte(hexDigit(c >> 4))
+			builder.WriteByte(hexDigit(c & 0x0F))
+		}
+	}
+
+	return builder.String()
+}
+
 func hexDigit(n byte) byte {
 	if n < 10 {
 		return '0' + n
@@ -6,134 +6,214 @@ import (
 	neturl "net/url"
 
 	"github.com/grafana/sobek"
+	"golang.org/x/text/encoding"
+
+	"github.com/oleiade/sobek-webapi-url/internal/webidl"
 )
 
-const iterableExtractorSource = `(function(obj) {
-	const result = [];
-	for (const item of obj) {
-		if (!Array.isArray(item) || item.length !== 2) {
-			throw new TypeError("Invalid argument");
-		}
-		result.push([String(item[0]), String(item[1])]);
-	}
-	return result;
-})`
+// RuntimeOptions configures the URL Web API installed by
+// RegisterRuntimeWithOptions.
+type RuntimeOptions struct {
+	// WarnOnDivergence emits a console.warn the first time a script runs into
+	// a result known to differ from the WHATWG URL Standard because of the
+	// other options, such as a custom scheme from Schemes or a query split
+	// on ";" because of SearchParams. It is a no-op when the runtime has no
+	// console.
+	WarnOnDivergence bool
+
+	// EnableExtensions installs non-standard helpers on top of the WHATWG
+	// API, such as URLSearchParams.prototype.toSorted() and the
+	// URL.prototype.hostnameUnicode getter.
+	EnableExtensions bool
+
+	// Validator, when non-nil, vets every URL constructed by scripts and
+	// every component setter call on them. A veto throws a TypeError.
+	Validator Validator
+
+	// OnValidationError, when non-nil, is called with every validation
+	// error the parser recovers from while scripts construct URLs, to
+	// surface inputs that parse but are not valid URL strings.
+	OnValidationError func(ValidationError)
+
+	// Strict makes the URL constructor throw a TypeError, and URL.parse()
+	// and URL.canParse() fail, on inputs with any validation error, such as
+	// a backslash used as a slash or a space in the path.
+	Strict bool
+
+	// Schemes, when non-nil, adds custom schemes, such as "grafana", to the
+	// standard ones for every URL scripts construct.
+	Schemes *SchemeRegistry
+
+	// Encoding, when non-nil, is the encoding override of the URLs scripts
+	// construct, for embedders emulating legacy non-UTF-8 pages: their query
+	// is percent-encoded in that charset as browsers do.
+	Encoding encoding.Encoding
+
+	// SearchParams tunes how URLSearchParams constructed by scripts, and
+	// the searchParams of their URLs, parse query strings, e.g. to also
+	// split pairs on ";".
+	SearchParams SearchParamsOptions
+}
 
 // RegisterRuntime exports the URL and URLSearchParams constructors
 // into the provided sobek runtime.
 func RegisterRuntime(rt *sobek.Runtime) error {
-	if err := bindURL(rt); err != nil {
+	return RegisterRuntimeWithOptions(rt, RuntimeOptions{})
+}
+
+// RegisterRuntimeWithOptions is like RegisterRuntime but configures the
+// installed constructors with opts.
+func RegisterRuntimeWithOptions(rt *sobek.Runtime, opts RuntimeOptions) error {
+	urlConstructor, searchParamsConstructor, err := newConstructors(rt, opts)
+	if err != nil {
 		return err
 	}
 
-	return bindURLSearchParams(rt)
+	if err := rt.Set("URL", urlConstructor); err != nil {
+		return fmt.Errorf("setting URL constructor: %w", err)
+	}
+
+	if err := rt.Set("URLSearchParams", searchParamsConstructor); err != nil {
+		return fmt.Errorf("setting URLSearchParams constructor: %w", err)
+	}
+
+	return nil
 }
 
-// bindURL registers the URL constructor and static methods.
+// newConstructors builds the URL and URLSearchParams constructors configured
+// with opts, without installing them anywhere.
+func newConstructors(rt *sobek.Runtime, opts RuntimeOptions) (*sobek.Object, *sobek.Object, error) {
+	parseOpts := ParseOptions{
+		Validator:         opts.Validator,
+		OnValidationError: opts.OnValidationError,
+		Strict:            opts.Strict,
+		Schemes:           opts.Schemes,
+		Encoding:          opts.Encoding,
+		SearchParams:      opts.SearchParams,
+	}
+	if opts.WarnOnDivergence {
+		parseOpts.OnDivergence = newConsoleWarner(rt)
+	}
+
+	urlConstructor, err := newURLConstructor(rt, opts, parseOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	searchParamsConstructor, err := newURLSearchParamsConstructor(rt, opts, parseOpts.OnDivergence)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return urlConstructor, searchParamsConstructor, nil
+}
+
+// newConsoleWarner returns a function that reports each distinct message
+// once through the runtime's console.warn.
+func newConsoleWarner(rt *sobek.Runtime) func(message string) {
+	warned := make(map[string]struct{})
+
+	return func(message string) {
+		if _, ok := warned[message]; ok {
+			return
+		}
+		warned[message] = struct{}{}
+
+		console := rt.Get("console")
+		if isNullish(console) {
+			return
+		}
+		consoleObj := console.ToObject(rt)
+		warn, ok := sobek.AssertFunction(consoleObj.Get("warn"))
+		if !ok {
+			return
+		}
+		_, _ = warn(consoleObj, rt.ToValue("URL: "+message))
+	}
+}
+
+// newURLConstructor builds the URL constructor and its static methods.
 //
 //nolint:funlen // This function is intentionally long as it defines all URL constructor logic in one place.
-func bindURL(rt *sobek.Runtime) error {
+func newURLConstructor(rt *sobek.Runtime, opts RuntimeOptions, parseOpts ParseOptions) (*sobek.Object, error) {
 	constructor := func(call sobek.ConstructorCall) *sobek.Object {
-		// Get the input argument (required)
-		inputArg := call.Argument(0)
-		if isNullish(inputArg) {
+		// Get the input argument (required). Like any USVString argument,
+		// undefined and null are converted to "undefined" and "null".
+		if len(call.Arguments) == 0 {
 			throwAsJSError(rt, invalidURLError())
 		}
 
-		input := inputArg.String()
-
-		// Get the optional base argument
-		var base string
-		baseArg := call.Argument(1)
-		if !isNullish(baseArg) {
-			// base can be a string or a URL object
-			if baseObj, ok := baseArg.Export().(*URL); ok {
-				base = baseObj.Href()
-			} else {
-				base = baseArg.String()
-			}
-		}
+		input := toUSVString(rt, call.Argument(0))
 
-		u, err := NewURL(input, base)
+		u, err := newURL(input, baseArgument(rt, call.Argument(1)), parseOpts)
 		if err != nil {
 			throwAsJSError(rt, err)
 		}
 
-		return newURLObject(rt, u, call.This)
-	}
-
-	// Set the constructor
-	if err := rt.Set("URL", constructor); err != nil {
-		return fmt.Errorf("setting URL constructor: %w", err)
+		return newURLObject(rt, u, call.This, opts)
 	}
 
 	// Get the URL constructor object to add static methods
-	urlConstructor := rt.Get("URL").ToObject(rt)
+	urlConstructor := rt.ToValue(constructor).ToObject(rt)
 
 	// Add URL.canParse static method
 	canParseFunc := func(call sobek.FunctionCall) sobek.Value {
-		inputArg := call.Argument(0)
-		// Convert undefined/null to "undefined"/"null" string as per JS behavior
-		var input string
-		if isNullish(inputArg) {
-			input = "undefined"
-		} else {
-			input = inputArg.String()
-		}
+		// undefined and null are converted to "undefined" and "null"
+		input := toUSVString(rt, call.Argument(0))
 
-		var base string
-		baseArg := call.Argument(1)
-		if !isNullish(baseArg) {
-			base = baseArg.String()
-		}
-
-		return rt.ToValue(CanParse(input, base))
+		_, err := newURL(input, baseArgument(rt, call.Argument(1)), parseOpts)
+		return rt.ToValue(err == nil)
 	}
 
 	if err := urlConstructor.Set("canParse", canParseFunc); err != nil {
-		return fmt.Errorf("setting URL.canParse: %w", err)
+		return nil, fmt.Errorf("setting URL.canParse: %w", err)
 	}
 
 	// Add URL.parse static method
 	parseFunc := func(call sobek.FunctionCall) sobek.Value {
-		inputArg := call.Argument(0)
-		// Convert undefined/null to "undefined"/"null" string as per JS behavior
-		var input string
-		if isNullish(inputArg) {
-			input = "undefined"
-		} else {
-			input = inputArg.String()
-		}
-
-		var base string
-		baseArg := call.Argument(1)
-		if !isNullish(baseArg) {
-			base = baseArg.String()
-		}
+		// undefined and null are converted to "undefined" and "null"
+		input := toUSVString(rt, call.Argument(0))
 
-		u := Parse(input, base)
-		if u == nil {
+		u, err := newURL(input, baseArgument(rt, call.Argument(1)), parseOpts)
+		if err != nil {
 			return sobek.Null()
 		}
 
 		// Create a new URL object
 		obj := rt.NewObject()
-		return newURLObject(rt, u, obj)
+		return newURLObject(rt, u, obj, opts)
 	}
 
 	if err := urlConstructor.Set("parse", parseFunc); err != nil {
-		return fmt.Errorf("setting URL.parse: %w", err)
+		return nil, fmt.Errorf("setting URL.parse: %w", err)
 	}
 
-	return nil
+	return urlConstructor, nil
+}
+
+// baseArgument converts the optional base argument of the URL constructor
+// and its static methods. Only undefined means "no base": null becomes the
+// "null" string and "" is kept, both of which then fail to parse as a base.
+func baseArgument(rt *sobek.Runtime, v sobek.Value) *string {
+	if v == nil || sobek.IsUndefined(v) {
+		return nil
+	}
+
+	// base can be a string or a URL object
+	if baseObj, ok := v.Export().(*URL); ok {
+		href := baseObj.Href()
+		return &href
+	}
+	base := toUSVString(rt, v)
+	return &base
 }
 
 // newURLObject creates a JS object wrapping a Go URL instance.
 //
 //nolint:funlen // This function is intentionally long as it defines all URL properties and methods.
-func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
+func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object, opts RuntimeOptions) *sobek.Object {
 	// Create the searchParams object once and cache it
-	searchParamsObj := newURLSearchParamsObject(rt, u.SearchParams())
+	searchParamsObj := newURLSearchParamsObject(rt, u.SearchParams(), rt.NewObject(), opts)
 
 	defineAccessor(rt, obj, "href",
 		func(_ sobek.FunctionCall) sobek.Value {
@@ -141,11 +221,11 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 		},
 		func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
-				if err := u.SetHref(call.Argument(0).String()); err != nil {
+				if err := u.SetHref(toUSVString(rt, call.Argument(0))); err != nil {
 					throwAsJSError(rt, err)
 				}
 				// Update searchParams reference
-				searchParamsObj = newURLSearchParamsObject(rt, u.SearchParams())
+				searchParamsObj = newURLSearchParamsObject(rt, u.SearchParams(), rt.NewObject(), opts)
 			}
 			return sobek.Undefined()
 		})
@@ -162,7 +242,9 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 		},
 		func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
-				u.SetProtocol(call.Argument(0).String())
+				if err := u.SetProtocol(toUSVString(rt, call.Argument(0))); err != nil {
+					throwAsJSError(rt, err)
+				}
 			}
 			return sobek.Undefined()
 		})
@@ -173,7 +255,9 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 		},
 		func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
-				u.SetUsername(call.Argument(0).String())
+				if err := u.SetUsername(toUSVString(rt, call.Argument(0))); err != nil {
+					throwAsJSError(rt, err)
+				}
 			}
 			return sobek.Undefined()
 		})
@@ -184,7 +268,9 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 		},
 		func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
-				u.SetPassword(call.Argument(0).String())
+				if err := u.SetPassword(toUSVString(rt, call.Argument(0))); err != nil {
+					throwAsJSError(rt, err)
+				}
 			}
 			return sobek.Undefined()
 		})
@@ -195,7 +281,9 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 		},
 		func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
-				u.SetHost(call.Argument(0).String())
+				if err := u.SetHost(toUSVString(rt, call.Argument(0))); err != nil {
+					throwAsJSError(rt, err)
+				}
 			}
 			return sobek.Undefined()
 		})
@@ -206,7 +294,9 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 		},
 		func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
-				u.SetHostname(call.Argument(0).String())
+				if err := u.SetHostname(toUSVString(rt, call.Argument(0))); err != nil {
+					throwAsJSError(rt, err)
+				}
 			}
 			return sobek.Undefined()
 		})
@@ -217,7 +307,9 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 		},
 		func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
-				u.SetPort(call.Argument(0).String())
+				if err := u.SetPort(toUSVString(rt, call.Argument(0))); err != nil {
+					throwAsJSError(rt, err)
+				}
 			}
 			return sobek.Undefined()
 		})
@@ -228,7 +320,9 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 		},
 		func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
-				u.SetPathname(call.Argument(0).String())
+				if err := u.SetPathname(toUSVString(rt, call.Argument(0))); err != nil {
+					throwAsJSError(rt, err)
+				}
 			}
 			return sobek.Undefined()
 		})
@@ -239,9 +333,11 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 		},
 		func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
-				u.SetSearch(call.Argument(0).String())
+				if err := u.SetSearch(toUSVString(rt, call.Argument(0))); err != nil {
+					throwAsJSError(rt, err)
+				}
 				// Update searchParams reference
-				searchParamsObj = newURLSearchParamsObject(rt, u.SearchParams())
+				searchParamsObj = newURLSearchParamsObject(rt, u.SearchParams(), rt.NewObject(), opts)
 			}
 			return sobek.Undefined()
 		})
@@ -258,11 +354,22 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 		},
 		func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
-				u.SetHash(call.Argument(0).String())
+				if err := u.SetHash(toUSVString(rt, call.Argument(0))); err != nil {
+					throwAsJSError(rt, err)
+				}
 			}
 			return sobek.Undefined()
 		})
 
+	if opts.EnableExtensions {
+		// hostnameUnicode getter (extension) - display form of the hostname
+		defineAccessor(rt, obj, "hostnameUnicode",
+			func(_ sobek.FunctionCall) sobek.Value {
+				return rt.ToValue(u.HostnameUnicode())
+			},
+			nil)
+	}
+
 	// Define toString method
 	toStringMethod := func(_ sobek.FunctionCall) sobek.Value {
 		return rt.ToValue(u.String())
@@ -282,92 +389,120 @@ func newURLObject(rt *sobek.Runtime, u *URL, obj *sobek.Object) *sobek.Object {
 	return obj
 }
 
-// bindURLSearchParams registers the URLSearchParams constructor.
-//
-//nolint:gocognit,nestif // Complex constructor logic to handle multiple input types as per WHATWG spec.
-func bindURLSearchParams(rt *sobek.Runtime) error {
+// newURLSearchParamsConstructor builds the URLSearchParams constructor.
+// Its instances report divergences from the WHATWG URL Standard to
+// onDivergence, when non-nil.
+func newURLSearchParamsConstructor(rt *sobek.Runtime, opts RuntimeOptions,
+	onDivergence func(message string),
+) (*sobek.Object, error) {
+	records, err := webidl.NewRecordConverter(rt)
+	if err != nil {
+		return nil, fmt.Errorf("building the URLSearchParams constructor: %w", err)
+	}
+
 	constructor := func(call sobek.ConstructorCall) *sobek.Object {
-		var sp *URLSearchParams
+		sp, err := newURLSearchParamsFromInit(call.Argument(0), records, opts.SearchParams, onDivergence)
+		if err != nil {
+			throwAsJSError(rt, err)
+		}
+		return newURLSearchParamsObject(rt, sp, call.This, opts)
+	}
 
-		initArg := call.Argument(0)
+	return rt.ToValue(constructor).ToObject(rt), nil
+}
 
-		if isNullish(initArg) {
-			// No argument or undefined/null - create empty params
-			sp = NewURLSearchParams()
-		} else {
-			// First check if it's a string
-			exported := initArg.Export()
-			if str, ok := exported.(string); ok {
-				sp = NewURLSearchParamsFromString(str)
-			} else if arr, ok := exported.([]interface{}); ok {
-				// Array of pairs
-				sp = NewURLSearchParams()
-				for _, item := range arr {
-					if pair, ok := item.([]interface{}); ok && len(pair) == 2 {
-						key := fmt.Sprintf("%v", pair[0])
-						value := fmt.Sprintf("%v", pair[1])
-						sp.Append(key, value)
-					} else if pair, ok := item.([]string); ok && len(pair) == 2 {
-						sp.Append(pair[0], pair[1])
-					} else {
-						throwAsJSError(rt, NewError(TypeError, "Invalid argument"))
-					}
-				}
-			} else {
-				// Check if it has Symbol.iterator (like URLSearchParams or arrays)
-				obj := initArg.ToObject(rt)
-				iteratorMethod := obj.GetSymbol(sobek.SymIterator)
-
-				if iteratorMethod != nil && !isNullish(iteratorMethod) {
-					// Has iterator - iterate over it
-					sp = NewURLSearchParams()
-					iterator, err := rt.RunString(iterableExtractorSource)
-					if err != nil {
-						throwAsJSError(rt, NewError(TypeError, "Invalid argument"))
-					}
-
-					iterFn, ok := sobek.AssertFunction(iterator)
-					if !ok {
-						throwAsJSError(rt, NewError(TypeError, "Invalid argument"))
-					}
-
-					result, err := iterFn(sobek.Undefined(), initArg)
-					if err != nil {
-						throwAsJSError(rt, NewError(TypeError, "Invalid argument"))
-					}
-
-					if resultArr, ok := result.Export().([]interface{}); ok {
-						for _, item := range resultArr {
-							if pair, ok := item.([]interface{}); ok && len(pair) == 2 {
-								sp.Append(fmt.Sprintf("%v", pair[0]), fmt.Sprintf("%v", pair[1]))
-							}
-						}
-					}
-				} else {
-					// Try as record (object with string keys)
-					sp = NewURLSearchParams()
-					for _, key := range obj.Keys() {
-						val := obj.Get(key)
-						if val != nil {
-							sp.Append(key, val.String())
-						}
-					}
-				}
-			}
+// searchParamsSlot keys the hidden property through which the bindings
+// recognize the objects wrapping a Go URLSearchParams.
+//
+//nolint:gochecknoglobals // A symbol is an identity, shared by every runtime.
+var searchParamsSlot = sobek.NewSymbol("URLSearchParams")
+
+// searchParamsInternals is the value of the searchParamsSlot property.
+type searchParamsInternals struct {
+	// owner is the object wrapping params, so that objects inheriting from
+	// it or given a copy of the property are not mistaken for it.
+	owner  *sobek.Object
+	params *URLSearchParams
+
+	// iterator is the object's original @@iterator method: copying params
+	// directly is only equivalent to iterating the object while it is
+	// still in place.
+	iterator sobek.Value
+}
+
+// unwrapSearchParams returns the Go URLSearchParams wrapped by obj, if obj
+// was created by newURLSearchParamsObject and still iterates it natively.
+func unwrapSearchParams(obj *sobek.Object) (*URLSearchParams, bool) {
+	slot := obj.GetSymbol(searchParamsSlot)
+	if slot == nil {
+		return nil, false
+	}
+	internals, ok := slot.Export().(*searchParamsInternals)
+	if !ok || internals.owner != obj {
+		return nil, false
+	}
+
+	iterator := obj.GetSymbol(sobek.SymIterator)
+	if iterator == nil || !iterator.SameAs(internals.iterator) {
+		return nil, false
+	}
+	return internals.params, true
+}
+
+// newURLSearchParamsFromInit converts the init argument of the
+// URLSearchParams constructor, a
+// (sequence<sequence<USVString>> or record<USVString, USVString> or USVString)
+// union: iterable objects are sequences of pairs, other objects records, and
+// anything else a query string. Only undefined means no init. The params
+// report divergences from the WHATWG URL Standard to onDivergence.
+func newURLSearchParamsFromInit(init sobek.Value, records *webidl.RecordConverter,
+	opts SearchParamsOptions, onDivergence func(message string),
+) (*URLSearchParams, error) {
+	if init == nil || sobek.IsUndefined(init) {
+		return newURLSearchParamsFromString("", opts, onDivergence), nil
+	}
+
+	if obj, ok := init.(*sobek.Object); ok {
+		// Another URLSearchParams is copied without the round trip through
+		// the iterator protocol, which would yield the same pairs.
+		if other, ok := unwrapSearchParams(obj); ok {
+			clone := other.Clone()
+			clone.opts = opts
+			clone.onDivergence = onDivergence
+			return clone, nil
+		}
+
+		method, err := webidl.IteratorMethod(obj)
+		if err != nil {
+			return nil, err
 		}
 
-		return newURLSearchParamsObject(rt, sp)
+		var entries [][2]string
+		if method != nil {
+			entries, err = webidl.ToPairSequence(obj, method)
+		} else {
+			entries, err = records.ToRecord(obj)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sp := NewURLSearchParamsFromEntries(entries)
+		sp.opts = opts
+		sp.onDivergence = onDivergence
+		return sp, nil
 	}
 
-	return rt.Set("URLSearchParams", constructor)
+	query, err := webidl.ToUSVString(init)
+	if err != nil {
+		return nil, err
+	}
+	return newURLSearchParamsFromString(query, opts, onDivergence), nil
 }
 
-// newURLSearchParamsObject creates a JS object wrapping a Go URLSearchParams instance.
+// newURLSearchParamsObject turns obj into a JS object wrapping a Go URLSearchParams instance.
 //
 //nolint:gocognit,cyclop,funlen // This function is intentionally complex as it defines all URLSearchParams methods.
-func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Object {
-	obj := rt.NewObject()
-
+func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams, obj *sobek.Object, opts RuntimeOptions) *sobek.Object {
 	// Set Symbol.toPrimitive for proper string conversion (params + '')
 	toPrimitiveMethod := func(_ sobek.FunctionCall) sobek.Value {
 		return rt.ToValue(sp.String())
@@ -381,8 +516,8 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 		if len(call.Arguments) < 2 {
 			return sobek.Undefined()
 		}
-		key := call.Argument(0).String()
-		value := call.Argument(1).String()
+		key := toUSVString(rt, call.Argument(0))
+		value := toUSVString(rt, call.Argument(1))
 		sp.Append(key, value)
 		return sobek.Undefined()
 	}
@@ -395,12 +530,8 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 		if len(call.Arguments) < 1 {
 			return sobek.Undefined()
 		}
-		key := call.Argument(0).String()
-		if len(call.Arguments) > 1 && !isNullish(call.Argument(1)) {
-			sp.DeletePair(key, call.Argument(1).String())
-		} else {
-			sp.DeleteAll(key)
-		}
+		key := toUSVString(rt, call.Argument(0))
+		sp.deleteMatching(key, optionalUSVString(rt, call.Argument(1)))
 		return sobek.Undefined()
 	}
 	if err := obj.Set("delete", deleteMethod); err != nil {
@@ -412,7 +543,7 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 		if len(call.Arguments) < 1 {
 			return sobek.Null()
 		}
-		key := call.Argument(0).String()
+		key := toUSVString(rt, call.Argument(0))
 		value, found := sp.Get(key)
 		if !found {
 			return sobek.Null()
@@ -428,7 +559,7 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 		if len(call.Arguments) < 1 {
 			return rt.NewArray()
 		}
-		key := call.Argument(0).String()
+		key := toUSVString(rt, call.Argument(0))
 		values := sp.GetAll(key)
 		return rt.ToValue(values)
 	}
@@ -441,11 +572,8 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 		if len(call.Arguments) < 1 {
 			return rt.ToValue(false)
 		}
-		key := call.Argument(0).String()
-		if len(call.Arguments) > 1 && !isNullish(call.Argument(1)) {
-			return rt.ToValue(sp.HasPair(key, call.Argument(1).String()))
-		}
-		return rt.ToValue(sp.HasKey(key))
+		key := toUSVString(rt, call.Argument(0))
+		return rt.ToValue(sp.hasMatching(key, optionalUSVString(rt, call.Argument(1))))
 	}
 	if err := obj.Set("has", hasMethod); err != nil {
 		panic(rt.NewGoError(err))
@@ -456,8 +584,8 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 		if len(call.Arguments) < 2 {
 			return sobek.Undefined()
 		}
-		key := call.Argument(0).String()
-		value := call.Argument(1).String()
+		key := toUSVString(rt, call.Argument(0))
+		value := toUSVString(rt, call.Argument(1))
 		sp.Set(key, value)
 		return sobek.Undefined()
 	}
@@ -482,6 +610,63 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 		panic(rt.NewGoError(err))
 	}
 
+	if opts.EnableExtensions {
+		// toSorted method (extension) - sorted copy, leaves sp and its owner untouched
+		toSortedMethod := func(_ sobek.FunctionCall) sobek.Value {
+			return newURLSearchParamsObject(rt, sp.ToSorted(), rt.NewObject(), opts)
+		}
+		if err := obj.Set("toSorted", toSortedMethod); err != nil {
+			panic(rt.NewGoError(err))
+		}
+
+		// appendAll method (extension) - appends an iterable of pairs with a
+		// single update of the owning URL
+		appendAllMethod := func(call sobek.FunctionCall) sobek.Value {
+			pairs, err := pairsArgument(call.Argument(0))
+			if err != nil {
+				throwAsJSError(rt, err)
+			}
+			sp.AppendAll(pairs)
+			return sobek.Undefined()
+		}
+		if err := obj.Set("appendAll", appendAllMethod); err != nil {
+			panic(rt.NewGoError(err))
+		}
+
+		// merge method (extension) - combines an iterable of pairs (or
+		// another URLSearchParams) according to a strategy
+		mergeMethod := func(call sobek.FunctionCall) sobek.Value {
+			pairs, err := pairsArgument(call.Argument(0))
+			if err != nil {
+				throwAsJSError(rt, err)
+			}
+			strategy, err := mergeStrategyArgument(call.Argument(1))
+			if err != nil {
+				throwAsJSError(rt, err)
+			}
+			sp.Merge(NewURLSearchParamsFromEntries(pairs), strategy)
+			return sobek.Undefined()
+		}
+		if err := obj.Set("merge", mergeMethod); err != nil {
+			panic(rt.NewGoError(err))
+		}
+
+		// toObject method (extension) - params grouped by name, repeated
+		// names holding an array of their values
+		toObjectMethod := func(_ sobek.FunctionCall) sobek.Value {
+			return searchParamsToObject(rt, sp)
+		}
+		if err := obj.Set("toObject", toObjectMethod); err != nil {
+			panic(rt.NewGoError(err))
+		}
+
+		// toJSON method (extension) - makes JSON.stringify(params) serialize
+		// the toObject() form
+		if err := obj.Set("toJSON", toObjectMethod); err != nil {
+			panic(rt.NewGoError(err))
+		}
+	}
+
 	// forEach method
 	forEachMethod := func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) < 1 {
@@ -513,7 +698,7 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 
 	// entries method - returns an iterator
 	entriesMethod := func(_ sobek.FunctionCall) sobek.Value {
-		return sliceIterator(rt, entriesToInterfaces(sp.Entries()))
+		return newSearchParamsIterator(rt, sp, iterateEntries)
 	}
 	if err := obj.Set("entries", entriesMethod); err != nil {
 		panic(rt.NewGoError(err))
@@ -521,7 +706,7 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 
 	// keys method - returns an iterator
 	keysMethod := func(_ sobek.FunctionCall) sobek.Value {
-		return sliceIterator(rt, sp.Keys())
+		return newSearchParamsIterator(rt, sp, iterateKeys)
 	}
 	if err := obj.Set("keys", keysMethod); err != nil {
 		panic(rt.NewGoError(err))
@@ -529,7 +714,7 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 
 	// values method - returns an iterator
 	valuesMethod := func(_ sobek.FunctionCall) sobek.Value {
-		return sliceIterator(rt, sp.Values())
+		return newSearchParamsIterator(rt, sp, iterateValues)
 	}
 	if err := obj.Set("values", valuesMethod); err != nil {
 		panic(rt.NewGoError(err))
@@ -545,12 +730,19 @@ func newURLSearchParamsObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Obj
 	// Symbol.iterator - make URLSearchParams iterable
 	// Returns the same as entries()
 	iteratorMethod := func(_ sobek.FunctionCall) sobek.Value {
-		return sliceIterator(rt, entriesToInterfaces(sp.Entries()))
+		return newSearchParamsIterator(rt, sp, iterateEntries)
 	}
-	if err := obj.SetSymbol(sobek.SymIterator, rt.ToValue(iteratorMethod)); err != nil {
+	iteratorValue := rt.ToValue(iteratorMethod)
+	if err := obj.SetSymbol(sobek.SymIterator, iteratorValue); err != nil {
 		panic(rt.NewGoError(fmt.Errorf("defining Symbol.iterator: %w", err)))
 	}
 
+	internals := &searchParamsInternals{owner: obj, params: sp, iterator: iteratorValue}
+	if err := obj.DefineDataPropertySymbol(searchParamsSlot, rt.ToValue(internals),
+		sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE); err != nil {
+		panic(rt.NewGoError(fmt.Errorf("defining the URLSearchParams slot: %w", err)))
+	}
+
 	return obj
 }
 
@@ -560,9 +752,43 @@ func throwAsJSError(rt *sobek.Runtime, err error) {
 	if errors.As(err, &urlErr) {
 		panic(urlErr.JSError(rt))
 	}
+
+	var conversionErr *webidl.TypeError
+	if errors.As(err, &conversionErr) {
+		panic(NewError(TypeError, conversionErr.Message).JSError(rt))
+	}
+
+	// Exceptions thrown by script code are rethrown as-is.
+	var exception *sobek.Exception
+	if errors.As(err, &exception) {
+		panic(exception)
+	}
+
 	panic(rt.NewGoError(err))
 }
 
+// toUSVString converts v to a string as WebIDL does for USVString arguments:
+// objects go through their toString or valueOf methods, lone surrogates
+// become U+FFFD and symbols throw a TypeError.
+func toUSVString(rt *sobek.Runtime, v sobek.Value) string {
+	s, err := webidl.ToUSVString(v)
+	if err != nil {
+		throwAsJSError(rt, err)
+	}
+	return s
+}
+
+// optionalUSVString converts an optional USVString argument: a missing
+// argument and an explicit undefined are both absent and yield nil, while
+// null is converted to "null" like any other value.
+func optionalUSVString(rt *sobek.Runtime, v sobek.Value) *string {
+	if v == nil || sobek.IsUndefined(v) {
+		return nil
+	}
+	s := toUSVString(rt, v)
+	return &s
+}
+
 // isNullish returns true if the value is null or undefined.
 func isNullish(v sobek.Value) bool {
 	return v == nil || sobek.IsUndefined(v) || sobek.IsNull(v)
@@ -585,24 +811,138 @@ func defineAccessor(rt *sobek.Runtime, obj *sobek.Object, name string,
 	}
 }
 
-func sliceIterator(rt *sobek.Runtime, data interface{}) sobek.Value {
-	arr := rt.ToValue(data).ToObject(rt)
-	iteratorFn := arr.GetSymbol(sobek.SymIterator)
-	if fn, ok := sobek.AssertFunction(iteratorFn); ok {
-		iter, _ := fn(arr)
-		return iter
+// pairsArgument converts an iterable of [name, value] pairs passed to an
+// extension method, such as an array of pairs or another URLSearchParams.
+func pairsArgument(v sobek.Value) ([][2]string, error) {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return nil, &webidl.TypeError{Message: "The argument must be an iterable of [name, value] pairs"}
+	}
+	if other, ok := unwrapSearchParams(obj); ok {
+		return other.Entries(), nil
+	}
+
+	method, err := webidl.IteratorMethod(obj)
+	if err != nil {
+		return nil, err
 	}
-	return arr
+	if method == nil {
+		return nil, &webidl.TypeError{Message: "The argument must be an iterable of [name, value] pairs"}
+	}
+	return webidl.ToPairSequence(obj, method)
 }
 
-func entriesToInterfaces(entries [][2]string) []interface{} {
-	result := make([]interface{}, len(entries))
-	for i, entry := range entries {
-		result[i] = []interface{}{entry[0], entry[1]}
+// mergeStrategyArgument converts the strategy argument of the merge
+// extension: "append" (the default), "keep-first" or "overwrite".
+func mergeStrategyArgument(v sobek.Value) (MergeStrategy, error) {
+	if v == nil || sobek.IsUndefined(v) {
+		return MergeAppend, nil
+	}
+
+	name, err := webidl.ToUSVString(v)
+	if err != nil {
+		return MergeAppend, err
+	}
+	switch name {
+	case "append":
+		return MergeAppend, nil
+	case "keep-first":
+		return MergeKeepFirst, nil
+	case "overwrite":
+		return MergeOverwrite, nil
+	default:
+		return MergeAppend, &webidl.TypeError{
+			Message: fmt.Sprintf(`Unknown merge strategy %q: expected "append", "keep-first" or "overwrite"`, name),
+		}
 	}
+}
+
+// searchParamsToObject builds the object returned by the toObject
+// extension: its properties follow the first appearance of each name, and
+// are defined rather than assigned so that a "__proto__" name is kept.
+func searchParamsToObject(rt *sobek.Runtime, sp *URLSearchParams) *sobek.Object {
+	result := rt.NewObject()
+
+	keys, values := sp.groupByKey()
+	for _, key := range keys {
+		group := values[key]
+
+		value := rt.ToValue(group[0])
+		if len(group) > 1 {
+			items := make([]interface{}, len(group))
+			for i, v := range group {
+				items[i] = v
+			}
+			value = rt.NewArray(items...)
+		}
+
+		if err := result.DefineDataProperty(key, value, sobek.FLAG_TRUE, sobek.FLAG_TRUE, sobek.FLAG_TRUE); err != nil {
+			panic(rt.NewGoError(err))
+		}
+	}
+
 	return result
 }
 
+// iterationKind selects what a URLSearchParams iterator yields for each
+// entry.
+type iterationKind int
+
+const (
+	iterateEntries iterationKind = iota
+	iterateKeys
+	iterateValues
+)
+
+// newSearchParamsIterator returns a JavaScript iterator over sp. As the
+// WHATWG iterators do, it only keeps an index into the list and reads the
+// entry at that index on every next() call, so mutations made during
+// iteration, including through the owning URL's search setter, are observed.
+func newSearchParamsIterator(rt *sobek.Runtime, sp *URLSearchParams, kind iterationKind) *sobek.Object {
+	iterator := rt.NewObject()
+	index := 0
+
+	next := func(_ sobek.FunctionCall) sobek.Value {
+		result := rt.NewObject()
+		if index >= len(sp.entries) {
+			_ = result.Set("value", sobek.Undefined())
+			_ = result.Set("done", true)
+			return result
+		}
+
+		entry := sp.entries[index]
+		index++
+
+		var value sobek.Value
+		switch kind {
+		case iterateKeys:
+			value = rt.ToValue(entry.key)
+		case iterateValues:
+			value = rt.ToValue(entry.value)
+		default:
+			// A native array rather than an exported Go slice, so that pairs
+			// behave like the ones browsers yield under spread, Array.from,
+			// JSON.stringify and Array.prototype methods.
+			value = rt.NewArray(entry.key, entry.value)
+		}
+		_ = result.Set("value", value)
+		_ = result.Set("done", false)
+		return result
+	}
+	if err := iterator.Set("next", next); err != nil {
+		panic(rt.NewGoError(err))
+	}
+
+	self := func(call sobek.FunctionCall) sobek.Value {
+		return call.This
+	}
+	if err := iterator.SetSymbol(sobek.SymIterator, rt.ToValue(self)); err != nil {
+		panic(rt.NewGoError(fmt.Errorf("defining Symbol.iterator: %w", err)))
+	}
+
+	return iterator
+}
+
 // ExtractURL extracts a URL object from a sobek.Value, if present.
 func ExtractURL(v sobek.Value) (*URL, bool) {
 	if isNullish(v) {
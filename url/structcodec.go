@@ -0,0 +1,281 @@
+package url
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// structField is a struct field bound to a query parameter.
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+//nolint:gochecknoglobals // Read-only reflection handles.
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Decode stores the params in the struct target points to. Each exported
+// field is bound to the parameter named by its `url` tag, or else its
+// `json` tag, or else the field name; a "-" name skips the field, and the
+// fields of embedded structs are bound as if they were the outer struct's.
+//
+// Fields may be strings, booleans, numbers or encoding.TextUnmarshaler
+// implementations, pointers to them, which are allocated when the parameter
+// is present, and slices of them, which receive every value of a repeated
+// parameter. Other fields receive the first value. Fields whose parameter is
+// absent are left untouched.
+func (sp *URLSearchParams) Decode(target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return NewError(TypeError, fmt.Sprintf("Decode target must be a non-nil pointer to a struct, got %T", target))
+	}
+	v = v.Elem()
+
+	for _, field := range structFields(v.Type()) {
+		values := sp.GetAll(field.name)
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := decodeField(v.FieldByIndex(field.index), values); err != nil {
+			return NewError(TypeError, fmt.Sprintf("Invalid value for query parameter %q: %v", field.name, err))
+		}
+	}
+
+	return nil
+}
+
+// EncodeFrom stores the fields of the struct src, or of the struct it
+// points to, in the params, binding fields to names as Decode does. The
+// values of each field replace those of its parameter, at the position of
+// its first occurrence; slices produce a repeated parameter, and an empty
+// slice removes it. Nil pointers, and empty fields tagged omitempty, are
+// skipped, leaving their parameter untouched. The owner's query string is
+// updated once.
+func (sp *URLSearchParams) EncodeFrom(src any) error {
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return NewError(TypeError, fmt.Sprintf("EncodeFrom source must be a struct or a pointer to one, got %T", src))
+	}
+
+	encoded := NewURLSearchParams()
+	removed := make(map[string]bool)
+	for _, field := range structFields(v.Type()) {
+		fv := v.FieldByIndex(field.index)
+		if field.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		values, err := encodeField(fv)
+		if err != nil {
+			return NewError(TypeError, fmt.Sprintf("Cannot encode query parameter %q: %v", field.name, err))
+		}
+		if len(values) == 0 && fv.Kind() == reflect.Slice {
+			removed[field.name] = true
+		}
+		for _, value := range values {
+			encoded.entries = append(encoded.entries, urlParam{key: field.name, value: value})
+		}
+	}
+
+	if len(removed) > 0 {
+		kept := make([]urlParam, 0, len(sp.entries))
+		for _, entry := range sp.entries {
+			if !removed[entry.key] {
+				kept = append(kept, entry)
+			}
+		}
+		sp.entries = kept
+	}
+
+	if len(removed) == 0 && len(encoded.entries) == 0 {
+		return nil
+	}
+
+	sp.merge(encoded, MergeOverwrite)
+	sp.syncOwner()
+	return nil
+}
+
+// structFields returns the fields of t bound to query parameters.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		name, opts, tagged := fieldTag(f)
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		if f.Anonymous && !tagged && f.Type.Kind() == reflect.Struct &&
+			!reflect.PointerTo(f.Type).Implements(textUnmarshalerType) {
+			for _, embedded := range structFields(f.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, structField{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+
+	return fields
+}
+
+// fieldTag returns the name and options of the `url` tag of f, or else of
+// its `json` tag, and whether either was set.
+func fieldTag(f reflect.StructField) (string, string, bool) {
+	tag, ok := f.Tag.Lookup("url")
+	if !ok {
+		tag, ok = f.Tag.Lookup("json")
+	}
+	if !ok {
+		return "", "", false
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	return name, opts, true
+}
+
+// decodeField stores values in v.
+func decodeField(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Slice && !v.Addr().Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := decodeValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	return decodeValue(v, values[0])
+}
+
+// decodeValue stores the single value s in v.
+func decodeValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(v.Elem(), s)
+	}
+
+	if unmarshaler, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() { //nolint:exhaustive // Other kinds are rejected below.
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+
+	return nil
+}
+
+// encodeField returns the values of v: none for a nil pointer, one per
+// item for a slice and one otherwise.
+func encodeField(v reflect.Value) ([]string, error) {
+	if v.Kind() == reflect.Slice && !v.Type().Implements(textMarshalerType) {
+		values := make([]string, 0, v.Len())
+		for i := range v.Len() {
+			item, err := encodeField(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, item...)
+		}
+		return values, nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		return encodeField(v.Elem())
+	}
+
+	value, err := encodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return []string{value}, nil
+}
+
+// encodeValue formats the single value v.
+func encodeValue(v reflect.Value) (string, error) {
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err
+	}
+	if v.CanAddr() {
+		if marshaler, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			text, err := marshaler.MarshalText()
+			return string(text), err
+		}
+	}
+
+	switch v.Kind() { //nolint:exhaustive // Other kinds are rejected below.
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported field type %s", v.Type())
+	}
+}
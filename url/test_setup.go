@@ -43,6 +43,7 @@ func newTestSetup(t testing.TB) *testSetup {
 	rt.SetFieldNameMapper(sobek.TagFieldNameMapper("json", true))
 
 	require.NoError(t, RegisterRuntime(rt))
+	require.NoError(t, RegisterDOMException(rt))
 
 	ts := &testSetup{rt: rt}
 	require.NoError(t, testExecuteTestScripts(ts))
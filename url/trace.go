@@ -0,0 +1,82 @@
+package url
+
+// ParserState names a step of the URL parser reported to a Tracer. The names
+// follow the states of the WHATWG basic URL parser.
+type ParserState string
+
+const (
+	// StateBase reports that the base URL was parsed.
+	StateBase ParserState = "base"
+	// StateSchemeStart is the initial state of the parser.
+	StateSchemeStart ParserState = "scheme start"
+	// StateScheme reads the scheme of the input.
+	StateScheme ParserState = "scheme"
+	// StateNoScheme handles inputs without a scheme, using the base.
+	StateNoScheme ParserState = "no scheme"
+	// StateSpecialRelativeOrAuthority handles "http:" inputs against an
+	// http base, which may be relative or start an authority.
+	StateSpecialRelativeOrAuthority ParserState = "special relative or authority"
+	// StatePathOrAuthority handles non-special inputs starting with "/".
+	StatePathOrAuthority ParserState = "path or authority"
+	// StateRelative resolves the input relative to the base.
+	StateRelative ParserState = "relative"
+	// StateRelativeSlash handles relative inputs starting with a slash,
+	// such as the scheme-relative "//host".
+	StateRelativeSlash ParserState = "relative slash"
+	// StateSpecialAuthoritySlashes expects the "//" of special URLs.
+	StateSpecialAuthoritySlashes ParserState = "special authority slashes"
+	// StateSpecialAuthorityIgnoreSlashes skips extra slashes of special URLs.
+	StateSpecialAuthorityIgnoreSlashes ParserState = "special authority ignore slashes"
+	// StateAuthority reads the credentials of the input.
+	StateAuthority ParserState = "authority"
+	// StateHost reads the host of the input.
+	StateHost ParserState = "host"
+	// StateHostname is the host state entered by the hostname setter.
+	StateHostname ParserState = "hostname"
+	// StatePort reads the port of the input.
+	StatePort ParserState = "port"
+	// StateFile handles file URLs.
+	StateFile ParserState = "file"
+	// StateFileSlash handles file URLs after their first slash.
+	StateFileSlash ParserState = "file slash"
+	// StateFileHost reads the host of file URLs.
+	StateFileHost ParserState = "file host"
+	// StatePathStart starts reading the path.
+	StatePathStart ParserState = "path start"
+	// StatePath reads the path segments.
+	StatePath ParserState = "path"
+	// StateOpaquePath reads opaque paths, such as the one of "mailto:x".
+	StateOpaquePath ParserState = "opaque path"
+	// StateQuery reads the query.
+	StateQuery ParserState = "query"
+	// StateFragment reads the fragment.
+	StateFragment ParserState = "fragment"
+	// StateDone reports that parsing succeeded.
+	StateDone ParserState = "done"
+	// StateFailure reports that parsing failed.
+	StateFailure ParserState = "failure"
+)
+
+// TraceEvent describes one step taken by the URL parser.
+type TraceEvent struct {
+	// State is the parser state the event was emitted from.
+	State ParserState
+
+	// Pointer is the code point offset into the input the parser was at,
+	// after leading and trailing C0 controls, spaces, tabs and newlines
+	// were removed.
+	Pointer int
+
+	// Message describes the decision the parser took.
+	Message string
+}
+
+// Tracer receives the events emitted while parsing a URL.
+type Tracer func(event TraceEvent)
+
+// emit sends an event to t, if tracing is enabled.
+func (t Tracer) emit(state ParserState, pointer int, message string) {
+	if t != nil {
+		t(TraceEvent{State: state, Pointer: pointer, Message: message})
+	}
+}
@@ -1,8 +1,11 @@
 package url
 
 import (
+	"fmt"
 	"net/url"
-	"strings"
+	"strconv"
+
+	"github.com/oleiade/sobek-webapi-url/hostparser"
 )
 
 // URL represents a WHATWG-style URL.
@@ -10,73 +13,122 @@ import (
 // Always construct a URL via NewURL; doing so guarantees that inner and
 // searchParams are non-nil and remain synchronized.
 type URL struct {
-	// inner stores the parsed WHATWG representation. It is never nil after
+	// inner stores the parsed WHATWG URL record. It is never nil after
 	// NewURL succeeds.
-	inner *url.URL
+	inner *urlRecord
 
 	// searchParams is the attached URLSearchParams instance. It is always
-	// non-nil and must stay in lockstep with inner.RawQuery.
+	// non-nil and must stay in lockstep with inner.query.
 	searchParams *URLSearchParams
+
+	// opts holds the options the URL was parsed with. The href setter
+	// reparses with them, and their Validator vets every component setter.
+	opts ParseOptions
 }
 
-// GoURL returns the underlying Go *url.URL representation.
+// GoURL returns a Go *url.URL parsed from the WHATWG serialization of u.
 // This lets Go callers interoperate with the standard library seamlessly.
+// It returns nil in the rare cases where net/url rejects the serialization.
 func (u *URL) GoURL() *url.URL {
-	return u.inner
+	goURL, err := url.Parse(u.Href())
+	if err != nil {
+		return nil
+	}
+	return goURL
 }
 
 // NewURL creates a new URL by parsing input relative to an optional base.
+// An empty base means no base; a non-empty base must be a valid URL, and
+// inputs without a scheme fail against bases with an opaque path such as
+// "aaa:b" (only fragments like "#x" can be resolved against those).
 //
 // The returned URL always has non-nil inner and searchParams fields. If parsing
 // fails, it returns an error that should be converted to a JavaScript
 // TypeError when thrown.
 func NewURL(input string, base string) (*URL, error) {
-	var baseURL *url.URL
-	var err error
+	return NewURLWithOptions(input, base, ParseOptions{})
+}
 
-	if base != "" {
-		baseURL, err = url.Parse(base)
-		if err != nil {
-			return nil, invalidURLError()
-		}
-		// WHATWG requires base URLs to be absolute; net/url would otherwise allow
-		// relative references, so enforce the stricter behavior here.
-		if !baseURL.IsAbs() {
-			return nil, invalidURLError()
-		}
+// NewURLWithOptions is like NewURL but lets callers tune the parser with opts.
+func NewURLWithOptions(input string, base string, opts ParseOptions) (*URL, error) {
+	if base == "" {
+		return newURL(input, nil, opts)
 	}
+	return newURL(input, &base, opts)
+}
 
-	var parsed *url.URL
-	if baseURL != nil {
-		ref, err := url.Parse(input)
-		if err != nil {
-			return nil, invalidURLError()
-		}
-		parsed = baseURL.ResolveReference(ref)
-	} else {
-		parsed, err = url.Parse(input)
+// newURL implements the URL constructor. Unlike NewURL, it distinguishes a
+// missing base (nil) from an empty one, which is not a valid URL.
+func newURL(input string, base *string, opts ParseOptions) (*URL, error) {
+	trace := opts.Trace
+
+	var baseRecord *urlRecord
+	if base != nil {
+		var err error
+		baseRecord, err = parseURL(*base, nil, ParseOptions{Schemes: opts.Schemes, Encoding: opts.Encoding})
 		if err != nil {
+			trace.emit(StateFailure, 0, "base URL is not a valid URL")
 			return nil, invalidURLError()
 		}
-		// Go's net/url accepts some inputs (e.g., "aaa:b") that WHATWG rejects.
-		// Enforce the WHATWG expectation that URLs without a base are absolute.
-		// Without a base, the URL must be absolute
-		if !parsed.IsAbs() {
-			return nil, invalidURLError()
-		}
+		trace.emit(StateBase, 0, "parsed base URL "+baseRecord.serialize(false))
 	}
 
-	// Validate scheme - reject empty scheme
-	if parsed.Scheme == "" {
-		return nil, invalidURLError()
+	record, strictErr, err := parseStrict(input, baseRecord, opts)
+	if err != nil {
+		return nil, err
+	}
+	if strictErr != nil {
+		return nil, strictModeError(strictErr)
 	}
 
-	u := &URL{inner: parsed}
-	u.initSearchParams()
+	u := &URL{inner: record}
+	u.initSearchParams(opts)
+	reportSchemeDivergence(record, opts.OnDivergence)
+
+	if err := validate(opts.Validator, Change{Component: ComponentURL, Next: u}); err != nil {
+		trace.emit(StateFailure, len(input), "rejected by validator")
+		return nil, err
+	}
+	u.opts = opts
+	trace.emit(StateDone, len(input), "parsed "+u.Href())
 
 	return u, nil
 }
 
+// parseStrict is like parseURL, but when opts.Strict is set it also fails
+// on the first validation error, after reporting it to OnValidationError,
+// and returns that error.
+func parseStrict(input string, base *urlRecord, opts ParseOptions) (*urlRecord, *ValidationError, error) {
+	var strictErr *ValidationError
+	if opts.Strict {
+		report := opts.OnValidationError
+		opts.OnValidationError = func(verr ValidationError) {
+			if strictErr == nil {
+				strictErr = &verr
+			}
+			if report != nil {
+				report(verr)
+			}
+		}
+	}
+
+	record, err := parseURL(input, base, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if strictErr != nil {
+		opts.Trace.emit(StateFailure, strictErr.Pointer, "strict mode rejects validation error "+string(strictErr.Type))
+		return nil, strictErr, nil
+	}
+	return record, nil, nil
+}
+
+// strictModeError returns the TypeError of inputs rejected by strict mode
+// because of verr.
+func strictModeError(verr *ValidationError) *Error {
+	return NewError(TypeError, fmt.Sprintf("Invalid URL: %s at position %d", verr.Type, verr.Pointer))
+}
+
 // Parse attempts to parse input relative to base and returns the URL or nil.
 // This is the implementation for the static URL.parse() method.
 func Parse(input string, base string) *URL {
@@ -99,178 +151,232 @@ func invalidURLError() *Error {
 	return NewError(TypeError, "Invalid URL")
 }
 
-// initSearchParams initializes the searchParams field from the current query string.
-func (u *URL) initSearchParams() {
+// invalidHrefError returns the TypeError the href setter throws, naming the
+// rejected input.
+func invalidHrefError(href string) *Error {
+	return NewError(TypeError, fmt.Sprintf("Invalid URL: %q", href))
+}
+
+// initSearchParams initializes the searchParams field from the current query
+// string, parsed according to the SearchParams options of opts.
+func (u *URL) initSearchParams(opts ParseOptions) {
 	// Don't use NewURLSearchParamsFromString here because it strips leading '?'
-	// but RawQuery might contain '?' as part of the actual query content.
+	// but the query might contain '?' as part of the actual query content.
 	u.searchParams = &URLSearchParams{
-		entries: parseFormEncoded(u.inner.RawQuery),
-		owner:   u,
+		owner:        u,
+		opts:         opts.SearchParams,
+		onDivergence: opts.OnDivergence,
+	}
+	u.searchParams.entries = u.searchParams.parseQuery(u.query())
+}
+
+// query returns the query of u, or "" when it has none.
+func (u *URL) query() string {
+	if u.inner.query == nil {
+		return ""
 	}
+	return *u.inner.query
 }
 
-// syncFromSearchParams updates inner.RawQuery from the attached searchParams.
+// syncFromSearchParams updates the query from the attached searchParams.
 // It is the only place that should mutate the underlying query once the URL
 // has been constructed, ensuring owner and params stay consistent.
 func (u *URL) syncFromSearchParams() {
 	serialized := u.searchParams.String()
-	u.inner.RawQuery = serialized
-	// Clear ForceQuery when query becomes empty
 	if serialized == "" {
-		u.inner.ForceQuery = false
+		u.inner.query = nil
+		return
 	}
+	u.inner.query = &serialized
 }
 
 // Href returns the full serialized URL.
 func (u *URL) Href() string {
-	return u.inner.String()
+	return u.inner.serialize(false)
 }
 
-// SetHref replaces the entire URL by parsing the new href value.
+// Serialize runs the WHATWG URL serializer
+// (https://url.spec.whatwg.org/#concept-url-serializer). With excludeFragment
+// set, the fragment and its "#" are left out, as when comparing documents or
+// building HTTP request targets.
+func (u *URL) Serialize(excludeFragment bool) string {
+	return u.inner.serialize(excludeFragment)
+}
+
+// SetHref replaces the entire URL by parsing the new href value with the
+// options the URL was parsed with, so that a strict URL rejects values with
+// validation errors and the encoding override still applies to the query.
+// Invalid values return a TypeError and leave the URL unchanged.
 func (u *URL) SetHref(href string) error {
-	parsed, err := url.Parse(href)
-	if err != nil {
-		return invalidURLError()
-	}
-	if !parsed.IsAbs() {
-		return invalidURLError()
-	}
-	u.inner = parsed
-	// Update the existing searchParams object so references held by JS stay valid.
-	u.updateSearchParams(parsed.RawQuery)
-	return nil
+	return u.mutate(ComponentHref, func(target *URL) error {
+		opts := u.opts
+		// Keep the schemes registered when the URL was first parsed.
+		opts.Schemes = u.inner.schemes
+
+		record, strictErr, err := parseStrict(href, nil, opts)
+		if err != nil {
+			return invalidHrefError(href)
+		}
+		if strictErr != nil {
+			return strictModeError(strictErr)
+		}
+		target.inner = record
+		reportSchemeDivergence(record, opts.OnDivergence)
+		// Update the existing searchParams object so references held by JS stay valid.
+		target.updateSearchParams(target.query())
+		return nil
+	})
 }
 
 // Protocol returns the scheme followed by a colon (e.g., "https:").
 func (u *URL) Protocol() string {
-	return u.inner.Scheme + ":"
+	return u.inner.scheme + ":"
 }
 
 // SetProtocol sets the URL's scheme from a value like "https:" or "https".
-func (u *URL) SetProtocol(protocol string) {
-	// Strip trailing colon if present
-	scheme := strings.TrimSuffix(protocol, ":")
-	scheme = strings.ToLower(scheme)
-	u.inner.Scheme = scheme
+// Values the protocol setter rejects, such as switching between special and
+// non-special schemes, leave the URL unchanged.
+func (u *URL) SetProtocol(protocol string) error {
+	return u.mutate(ComponentProtocol, func(target *URL) error {
+		target.inner.setProtocol(protocol)
+		return nil
+	})
 }
 
 // Username returns the username portion of the URL.
 func (u *URL) Username() string {
-	if u.inner.User == nil {
-		return ""
-	}
-	return u.inner.User.Username()
-}
-
-// SetUsername sets the username portion of the URL.
-func (u *URL) SetUsername(username string) {
-	if u.inner.User == nil {
-		u.inner.User = url.User(username)
-	} else {
-		password, hasPassword := u.inner.User.Password()
-		if hasPassword {
-			u.inner.User = url.UserPassword(username, password)
-		} else {
-			u.inner.User = url.User(username)
-		}
-	}
+	return u.inner.username
+}
+
+// SetUsername sets the username portion of the URL. It does nothing on URLs
+// that cannot have credentials, such as file: URLs.
+func (u *URL) SetUsername(username string) error {
+	return u.mutate(ComponentUsername, func(target *URL) error {
+		target.inner.setUsername(username)
+		return nil
+	})
 }
 
 // Password returns the password portion of the URL.
 func (u *URL) Password() string {
-	if u.inner.User == nil {
-		return ""
-	}
-	password, _ := u.inner.User.Password()
-	return password
+	return u.inner.password
 }
 
-// SetPassword sets the password portion of the URL.
-func (u *URL) SetPassword(password string) {
-	username := ""
-	if u.inner.User != nil {
-		username = u.inner.User.Username()
-	}
-	u.inner.User = url.UserPassword(username, password)
+// SetPassword sets the password portion of the URL. It does nothing on URLs
+// that cannot have credentials, such as file: URLs.
+func (u *URL) SetPassword(password string) error {
+	return u.mutate(ComponentPassword, func(target *URL) error {
+		target.inner.setPassword(password)
+		return nil
+	})
+}
+
+// HasHost reports whether u has a host. The host may be empty, as in
+// "file:///p" or "foo://", whereas "foo:/p" and "mailto:x" have none; Host
+// and Hostname return "" in both cases.
+func (u *URL) HasHost() bool {
+	return u.inner.host != nil
 }
 
 // Host returns the host and port (if non-default) combined.
 func (u *URL) Host() string {
-	return u.inner.Host
+	if u.inner.host == nil {
+		return ""
+	}
+	if u.inner.port == noPort {
+		return *u.inner.host
+	}
+	return *u.inner.host + ":" + strconv.Itoa(u.inner.port)
 }
 
-// SetHost sets the host (and optionally port) of the URL.
-func (u *URL) SetHost(host string) {
-	u.inner.Host = host
+// SetHost sets the host (and optionally port) of the URL. Invalid hosts
+// leave the URL unchanged.
+func (u *URL) SetHost(host string) error {
+	return u.mutate(ComponentHost, func(target *URL) error {
+		target.inner.setHost(host)
+		return nil
+	})
 }
 
 // Hostname returns just the hostname portion (without port).
 func (u *URL) Hostname() string {
-	return u.inner.Hostname()
+	if u.inner.host == nil {
+		return ""
+	}
+	return *u.inner.host
 }
 
-// SetHostname sets the hostname portion without affecting the port.
-func (u *URL) SetHostname(hostname string) {
-	port := u.inner.Port()
-	if port != "" {
-		u.inner.Host = hostname + ":" + port
-	} else {
-		u.inner.Host = hostname
+// HostnameUnicode returns the hostname in its Unicode display form, such as
+// "bücher.de" for "xn--bcher-kva.de". Hostnames that are not domains of
+// special URLs, or whose punycode does not decode, are returned as-is.
+func (u *URL) HostnameUnicode() string {
+	hostname := u.Hostname()
+	if !u.inner.isSpecial() || hostname == "" {
+		return hostname
 	}
+	unicode, ok := hostparser.DomainToUnicode(hostname)
+	if !ok {
+		return hostname
+	}
+	return unicode
+}
+
+// SetHostname sets the hostname portion without affecting the port. Invalid
+// hostnames leave the URL unchanged.
+func (u *URL) SetHostname(hostname string) error {
+	return u.mutate(ComponentHostname, func(target *URL) error {
+		target.inner.setHostname(hostname)
+		return nil
+	})
 }
 
 // Port returns the port as a string, or empty if not specified.
 func (u *URL) Port() string {
-	return u.inner.Port()
+	if u.inner.port == noPort {
+		return ""
+	}
+	return strconv.Itoa(u.inner.port)
 }
 
-// SetPort sets the port portion of the URL.
-func (u *URL) SetPort(port string) {
-	hostname := u.inner.Hostname()
-	if port == "" {
-		u.inner.Host = hostname
-	} else {
-		u.inner.Host = hostname + ":" + port
-	}
+// SetPort sets the port portion of the URL. An empty port removes it, and
+// invalid ports leave the URL unchanged.
+func (u *URL) SetPort(port string) error {
+	return u.mutate(ComponentPort, func(target *URL) error {
+		target.inner.setPort(port)
+		return nil
+	})
 }
 
 // Pathname returns the path portion of the URL.
 func (u *URL) Pathname() string {
-	path := u.inner.Path
-	if path == "" {
-		return "/"
-	}
-	// Ensure path starts with /
-	if !strings.HasPrefix(path, "/") {
-		return "/" + path
-	}
-	return path
+	return u.inner.serializePath()
 }
 
-// SetPathname sets the path portion of the URL.
-func (u *URL) SetPathname(pathname string) {
-	u.inner.Path = pathname
+// SetPathname sets the path portion of the URL. It does nothing on URLs
+// with an opaque path.
+func (u *URL) SetPathname(pathname string) error {
+	return u.mutate(ComponentPathname, func(target *URL) error {
+		target.inner.setPathname(pathname)
+		return nil
+	})
 }
 
 // Search returns the query string including the leading "?" if non-empty.
 func (u *URL) Search() string {
-	if u.inner.RawQuery == "" {
+	if u.query() == "" {
 		return ""
 	}
-	return "?" + u.inner.RawQuery
+	return "?" + u.query()
 }
 
 // SetSearch sets the query string (with or without leading "?").
-func (u *URL) SetSearch(search string) {
-	// Strip leading ? if present
-	search = strings.TrimPrefix(search, "?")
-	u.inner.RawQuery = search
-	// Clear ForceQuery when query becomes empty
-	if search == "" {
-		u.inner.ForceQuery = false
-	}
-	// Update the existing searchParams object instead of creating a new one
-	u.updateSearchParams(search)
+func (u *URL) SetSearch(search string) error {
+	return u.mutate(ComponentSearch, func(target *URL) error {
+		target.inner.setSearch(search)
+		// Update the existing searchParams object instead of creating a new one
+		target.updateSearchParams(target.query())
+		return nil
+	})
 }
 
 // updateSearchParams updates the existing searchParams with new query string.
@@ -280,7 +386,7 @@ func (u *URL) updateSearchParams(query string) {
 	u.searchParams.entries = u.searchParams.entries[:0]
 	// Parse new query and add entries
 	if query != "" {
-		newEntries := parseFormEncoded(query)
+		newEntries := u.searchParams.parseQuery(query)
 		u.searchParams.entries = append(u.searchParams.entries, newEntries...)
 	}
 }
@@ -307,32 +413,27 @@ func (u *URL) SearchParams() *URLSearchParams {
 
 // Hash returns the fragment including the leading "#" if non-empty.
 func (u *URL) Hash() string {
-	if u.inner.Fragment == "" {
+	if u.inner.fragment == nil || *u.inner.fragment == "" {
 		return ""
 	}
-	return "#" + u.inner.Fragment
+	return "#" + *u.inner.fragment
 }
 
 // SetHash sets the fragment (with or without leading "#").
-func (u *URL) SetHash(hash string) {
-	// Strip leading # if present
-	u.inner.Fragment = strings.TrimPrefix(hash, "#")
+func (u *URL) SetHash(hash string) error {
+	return u.mutate(ComponentHash, func(target *URL) error {
+		target.inner.setHash(hash)
+		return nil
+	})
 }
 
-// Origin returns the origin of the URL.
+// Origin returns the serialized origin of the URL.
 //
-// For http, https, ws, wss, and ftp schemes, this returns "scheme://host".
-// For file scheme and other schemes, this returns "null".
+// For http, https, ws, wss, and ftp schemes, this returns "scheme://host",
+// followed by the port when it is not the default one. For file scheme and
+// other schemes, the origin is opaque and this returns "null".
 func (u *URL) Origin() string {
-	switch u.inner.Scheme {
-	case "http", "https", "ws", "wss":
-		return u.inner.Scheme + "://" + u.inner.Host
-	case "ftp":
-		return u.inner.Scheme + "://" + u.inner.Host
-	default:
-		// file: and other schemes return "null"
-		return "null"
-	}
+	return u.inner.origin()
 }
 
 // String returns the serialized URL (same as Href).
@@ -344,3 +445,49 @@ func (u *URL) String() string {
 func (u *URL) ToJSON() string {
 	return u.Href()
 }
+
+// TrimToFit removes query parameters until the serialized URL is at most
+// maxLen bytes long, for targets that enforce URL length limits.
+//
+// Parameters are dropped by key in dropOrder priority: every entry of
+// dropOrder[0] (last occurrence first) before any entry of dropOrder[1], and
+// so on, stopping as soon as the URL fits. It returns the dropped name/value
+// pairs in removal order. When the URL cannot fit even after dropping every
+// listed key, it returns a RangeError and leaves the URL unchanged.
+func (u *URL) TrimToFit(maxLen int, dropOrder []string) ([][2]string, error) {
+	if len(u.Href()) <= maxLen {
+		return nil, nil
+	}
+
+	entries := make([]urlParam, len(u.searchParams.entries))
+	copy(entries, u.searchParams.entries)
+
+	hrefLen := func() int {
+		inner := *u.inner
+		inner.query = nil
+		if query := u.searchParams.opts.serialize(entries); query != "" {
+			inner.query = &query
+		}
+		return len(inner.serialize(false))
+	}
+
+	var dropped [][2]string
+	for _, key := range dropOrder {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].key != key {
+				continue
+			}
+
+			dropped = append(dropped, [2]string{entries[i].key, entries[i].value})
+			entries = append(entries[:i], entries[i+1:]...)
+
+			if hrefLen() <= maxLen {
+				u.searchParams.entries = entries
+				u.syncFromSearchParams()
+				return dropped, nil
+			}
+		}
+	}
+
+	return nil, NewError(RangeError, fmt.Sprintf("URL cannot be trimmed to %d bytes", maxLen))
+}
@@ -0,0 +1,65 @@
+package url
+
+// ValidationErrorType names a non-fatal validation error of the WHATWG URL
+// Standard (https://url.spec.whatwg.org/#validation-error-types).
+type ValidationErrorType string
+
+const (
+	// ValidationInvalidURLUnit reports a code point that is not a URL code
+	// point, a "%" not followed by two hex digits, or leading, trailing or
+	// embedded whitespace that the parser removed.
+	ValidationInvalidURLUnit ValidationErrorType = "invalid-URL-unit"
+	// ValidationSpecialSchemeMissingFollowingSolidus reports a special URL
+	// whose scheme is not followed by "//", such as "http:example.com".
+	ValidationSpecialSchemeMissingFollowingSolidus ValidationErrorType = "special-scheme-missing-following-solidus"
+	// ValidationInvalidReverseSolidus reports a "\" used as a path separator
+	// in a special URL.
+	ValidationInvalidReverseSolidus ValidationErrorType = "invalid-reverse-solidus"
+	// ValidationInvalidCredentials reports a URL with a username or password.
+	ValidationInvalidCredentials ValidationErrorType = "invalid-credentials"
+	// ValidationFileInvalidWindowsDriveLetter reports a relative file URL
+	// starting with a Windows drive letter.
+	ValidationFileInvalidWindowsDriveLetter ValidationErrorType = "file-invalid-Windows-drive-letter"
+	// ValidationFileInvalidWindowsDriveLetterHost reports a file URL whose
+	// host is a Windows drive letter, such as "file://C:/".
+	ValidationFileInvalidWindowsDriveLetterHost ValidationErrorType = "file-invalid-Windows-drive-letter-host"
+	// ValidationIPv4EmptyPart reports an IPv4 address ending with a dot.
+	ValidationIPv4EmptyPart ValidationErrorType = "IPv4-empty-part"
+	// ValidationIPv4NonDecimalPart reports an IPv4 address with a
+	// hexadecimal or octal part.
+	ValidationIPv4NonDecimalPart ValidationErrorType = "IPv4-non-decimal-part"
+)
+
+// ValidationError is a validation error the parser recovered from: the
+// input still parses, but is not a valid URL string.
+type ValidationError struct {
+	// Type names the validation error.
+	Type ValidationErrorType
+
+	// Pointer is the code point offset in the input, after the removal of
+	// leading and trailing whitespace, at which the error was found.
+	Pointer int
+}
+
+// isURLCodePoint reports whether c is a URL code point
+// (https://url.spec.whatwg.org/#url-code-points).
+func isURLCodePoint(c rune) bool {
+	switch {
+	case isASCIIAlphaRune(c) || isASCIIDigitRune(c):
+		return true
+	case c < 0x80:
+		switch c {
+		case '!', '$', '&', '\'', '(', ')', '*', '+', ',', '-', '.', '/', ':', ';', '=', '?', '@', '_', '~':
+			return true
+		}
+		return false
+	case c < 0xA0 || c > 0x10FFFD:
+		return false
+	case c >= 0xD800 && c <= 0xDFFF:
+		return false
+	case c >= 0xFDD0 && c <= 0xFDEF:
+		return false
+	}
+	// The last two code points of every plane are noncharacters.
+	return c&0xFFFE != 0xFFFE
+}
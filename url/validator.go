@@ -0,0 +1,102 @@
+package url
+
+import "errors"
+
+// Component names the part of a URL a Change affects.
+type Component string
+
+// Components reported in Change values.
+const (
+	// ComponentURL reports the construction of a new URL.
+	ComponentURL      Component = "url"
+	ComponentHref     Component = "href"
+	ComponentProtocol Component = "protocol"
+	ComponentUsername Component = "username"
+	ComponentPassword Component = "password"
+	ComponentHost     Component = "host"
+	ComponentHostname Component = "hostname"
+	ComponentPort     Component = "port"
+	ComponentPathname Component = "pathname"
+	ComponentSearch   Component = "search"
+	ComponentHash     Component = "hash"
+	// ComponentUpdate reports a batch of changes made through URL.Update.
+	ComponentUpdate Component = "update"
+)
+
+// Change describes a URL construction or mutation submitted to a Validator.
+type Change struct {
+	// Component is the component being set, or ComponentURL on construction.
+	Component Component
+
+	// Previous is the URL before the change. It is nil on construction.
+	Previous *URL
+
+	// Next is the URL as it will be if the change is accepted. Validators
+	// must treat it as read-only.
+	Next *URL
+}
+
+// Validator vets URL constructions and component setters, for embedding in
+// multi-tenant or security-sensitive runtimes: forbidding credentials,
+// forbidding https to http downgrades, restricting hosts to an allowlist...
+//
+// Returning an error vetoes the change: the URL is left untouched, Go
+// callers receive the error and JavaScript callers get a TypeError. Changes
+// made through URL.searchParams are not submitted to the validator.
+type Validator interface {
+	Validate(change Change) error
+}
+
+// ValidatorFunc adapts a function to the Validator interface.
+type ValidatorFunc func(change Change) error
+
+// Validate calls f(change).
+func (f ValidatorFunc) Validate(change Change) error {
+	return f(change)
+}
+
+// validate submits change to v, converting a veto into a TypeError.
+func validate(v Validator, change Change) error {
+	if v == nil {
+		return nil
+	}
+
+	err := v.Validate(change)
+	if err == nil {
+		return nil
+	}
+
+	var urlErr *Error
+	if errors.As(err, &urlErr) {
+		return urlErr
+	}
+	return NewError(TypeError, err.Error())
+}
+
+// mutate applies a setter to u. The setter runs against a copy that is only
+// committed once it succeeds and the validator, if any, accepts it, so a
+// failing setter leaves u and its searchParams exactly as they were.
+func (u *URL) mutate(component Component, apply func(target *URL) error) error {
+	next := u.clone()
+	if err := apply(next); err != nil {
+		return err
+	}
+
+	if err := validate(u.opts.Validator, Change{Component: component, Previous: u, Next: next}); err != nil {
+		return err
+	}
+
+	u.inner = next.inner
+	u.searchParams.entries = next.searchParams.entries
+	return nil
+}
+
+// clone returns a detached copy of u with its own searchParams and no
+// options.
+func (u *URL) clone() *URL {
+	c := &URL{inner: u.inner.clone()}
+	c.searchParams = u.searchParams.Clone()
+	c.searchParams.owner = c
+	c.searchParams.onDivergence = u.searchParams.onDivergence
+	return c
+}